@@ -0,0 +1,234 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// EnvMapUnit is the texture unit of the cube map that the objects reflect
+// (see SimpleObjVar.Env), which is next to ShadowMapUnit.
+const EnvMapUnit int32 = 14
+
+// CubeMap is a cube map texture on the GPU, which is used by Skybox and the
+// environment reflections of the objects.
+//
+// The cube maps which are not deleted by Delete() are deleted by Terminate().
+type CubeMap struct {
+	// ID is the name of the GL texture.
+	ID uint32
+
+	// Size is the width and the height of the faces in pixels.
+	Size int32
+
+	// Format is the pixel format of the faces.
+	Format TextureFormat
+
+	// SRGB tells whether the cube map is an sRGB texture.
+	SRGB bool
+}
+
+// cubeMaps are the cube maps that are not deleted yet.
+var cubeMaps = map[*CubeMap]bool{}
+
+// NewCubeMapFromFiles decodes the PNG, JPEG or GIF files of the faces and
+// uploads them as a cube map. The faces are in the order of +X (right),
+// -X (left), +Y (top), -Y (bottom), +Z (front) and -Z (back).
+func NewCubeMapFromFiles(files [6]string, opts TextureOptions) (*CubeMap, error) {
+	var faces [6]image.Image
+	for i, file := range files {
+		img, err := decodeImageFile(file)
+		if err != nil {
+			return nil, err
+		}
+		faces[i] = img
+	}
+	return NewCubeMap(faces, opts)
+}
+
+// NewCubeMap uploads the images of the faces as a cube map. The faces are in
+// the order of +X, -X, +Y, -Y, +Z and -Z, and they should be squares of the
+// same size. The wrap modes and FlipY of opts are ignored, since the faces
+// are always clamped to their edges.
+func NewCubeMap(faces [6]image.Image, opts TextureOptions) (*CubeMap, error) {
+	internalFormat, format, err := textureGLFormats(opts)
+	if err != nil {
+		return nil, err
+	}
+	size := faces[0].Bounds().Dx()
+	for i, face := range faces {
+		b := face.Bounds()
+		if b.Dx() != b.Dy() || b.Dx() != size || size == 0 {
+			return nil, fmt.Errorf(
+				"face %v is %vx%v, the faces should be squares of the same size",
+				i, b.Dx(), b.Dy(),
+			)
+		}
+	}
+
+	cm := &CubeMap{
+		Size:   int32(size),
+		Format: opts.Format,
+		SRGB:   opts.SRGB,
+	}
+	gl.GenTextures(1, &cm.ID)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cm.ID)
+	var alignment int32
+	gl.GetIntegerv(gl.UNPACK_ALIGNMENT, &alignment)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	for i, face := range faces {
+		pix, _, _ := texturePixels(face, opts.Format, false)
+		gl.TexImage2D(
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), 0, internalFormat,
+			cm.Size, cm.Size, 0, format, gl.UNSIGNED_BYTE, gl.Ptr(pix),
+		)
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, alignment)
+
+	minFilter, magFilter := opts.MinFilter, opts.MagFilter
+	if minFilter == 0 {
+		minFilter = gl.LINEAR
+		if opts.Mipmaps {
+			minFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	}
+	if magFilter == 0 {
+		magFilter = gl.LINEAR
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, magFilter)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	if opts.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	// filter across the edges of the faces
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	cubeMaps[cm] = true
+	return cm, nil
+}
+
+// NewCubeMapFromEquirectFile decodes a PNG, JPEG or GIF file of an
+// equirectangular panorama and converts it into a cube map.
+func NewCubeMapFromEquirectFile(file string, size int, opts TextureOptions) (*CubeMap, error) {
+	img, err := decodeImageFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewCubeMapFromEquirect(img, size, opts)
+}
+
+// NewCubeMapFromEquirect converts an equirectangular panorama (e.g. a 2:1
+// image of a 360 degree photo) into a cube map whose faces are size*size.
+// The center of the panorama is at -Z, which is in front of the default
+// Viewpoint, and the top of the panorama is +Y.
+func NewCubeMapFromEquirect(img image.Image, size int, opts TextureOptions) (*CubeMap, error) {
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("the image is empty")
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid cube map size %v", size)
+	}
+	src := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	var faces [6]image.Image
+	for i := range faces {
+		face := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				u := 2*(float32(x)+0.5)/float32(size) - 1
+				v := 2*(float32(y)+0.5)/float32(size) - 1
+				face.SetNRGBA(x, y, sampleEquirect(src, cubeMapDirection(i, u, v)))
+			}
+		}
+		faces[i] = face
+	}
+	return NewCubeMap(faces, opts)
+}
+
+// cubeMapDirection returns the direction of the point (u, v) on the face,
+// where u and v are from -1 to 1 and v is downward like the rows of images.
+func cubeMapDirection(face int, u, v float32) mgl32.Vec3 {
+	switch face {
+	case 0: // +X
+		return mgl32.Vec3{1, -v, -u}
+	case 1: // -X
+		return mgl32.Vec3{-1, -v, u}
+	case 2: // +Y
+		return mgl32.Vec3{u, 1, v}
+	case 3: // -Y
+		return mgl32.Vec3{u, -1, -v}
+	case 4: // +Z
+		return mgl32.Vec3{u, -v, 1}
+	}
+	return mgl32.Vec3{-u, -v, -1} // -Z
+}
+
+// sampleEquirect samples the equirectangular image in the direction with
+// bilinear filtering.
+func sampleEquirect(img *image.NRGBA, dir mgl32.Vec3) color.NRGBA {
+	dir = dir.Normalize()
+	lon := math.Atan2(float64(dir.X()), float64(-dir.Z()))
+	lat := math.Asin(float64(mgl32.Clamp(dir.Y(), -1, 1)))
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	fx := (0.5+lon/(2*math.Pi))*float64(w) - 0.5
+	fy := (0.5-lat/math.Pi)*float64(h) - 0.5
+
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	tx, ty := fx-float64(x0), fy-float64(y0)
+	pixel := func(x, y int) [4]float64 {
+		// wrap around horizontally, clamp vertically
+		x = ((x % w) + w) % w
+		if y < 0 {
+			y = 0
+		} else if y >= h {
+			y = h - 1
+		}
+		c := img.NRGBAAt(x, y)
+		return [4]float64{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+	}
+	p00, p10 := pixel(x0, y0), pixel(x0+1, y0)
+	p01, p11 := pixel(x0, y0+1), pixel(x0+1, y0+1)
+	var out [4]uint8
+	for i := range out {
+		top := p00[i]*(1-tx) + p10[i]*tx
+		bottom := p01[i]*(1-tx) + p11[i]*tx
+		out[i] = uint8(math.Round(top*(1-ty) + bottom*ty))
+	}
+	return color.NRGBA{out[0], out[1], out[2], out[3]}
+}
+
+// Bind binds the cube map to the texture unit, i.e. gl.TEXTURE0 + unit.
+func (cm *CubeMap) Bind(unit int32) {
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cm.ID)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// bindEnvMap binds the cube map that the object reflects to EnvMapUnit, and
+// tells the shader whether there's one by the "envEnabled" uniform variable.
+func bindEnvMap(env *CubeMap, enabledLocation int32) {
+	if env == nil {
+		gl.Uniform1i(enabledLocation, 0)
+		return
+	}
+	env.Bind(EnvMapUnit)
+	gl.Uniform1i(enabledLocation, 1)
+}
+
+// Delete frees the cube map.
+func (cm *CubeMap) Delete() {
+	if cm.ID != 0 {
+		gl.DeleteTextures(1, &cm.ID)
+	}
+	cm.ID = 0
+	delete(cubeMaps, cm)
+}
@@ -0,0 +1,81 @@
+package sgl
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// glCubeMapCoords returns the face and the (s, t) coordinates from -1 to 1
+// that OpenGL samples in the direction, by the table of the major axes in
+// the OpenGL specification.
+func glCubeMapCoords(dir mgl32.Vec3) (int, float32, float32) {
+	x, y, z := dir.X(), dir.Y(), dir.Z()
+	ax, ay, az := math.Abs(float64(x)), math.Abs(float64(y)), math.Abs(float64(z))
+	switch {
+	case ax >= ay && ax >= az && x > 0:
+		return 0, -z / x, -y / x
+	case ax >= ay && ax >= az:
+		return 1, z / -x, -y / -x
+	case ay >= az && y > 0:
+		return 2, x / y, z / y
+	case ay >= az:
+		return 3, x / -y, -z / -y
+	case z > 0:
+		return 4, x / z, -y / z
+	}
+	return 5, -x / -z, -y / -z
+}
+
+func TestCubeMapDirection(t *testing.T) {
+	for face := 0; face < 6; face++ {
+		for _, uv := range [][2]float32{{0, 0}, {-0.5, -0.5}, {0.5, -0.25}, {0.25, 0.75}, {-0.9, 0.9}} {
+			dir := cubeMapDirection(face, uv[0], uv[1])
+			gotFace, s, tc := glCubeMapCoords(dir)
+			if gotFace != face || math.Abs(float64(s-uv[0])) > 1e-6 || math.Abs(float64(tc-uv[1])) > 1e-6 {
+				t.Errorf("face %v at %v: direction %v is sampled at face %v (%v, %v)", face, uv, dir, gotFace, s, tc)
+			}
+		}
+	}
+}
+
+func TestSampleEquirect(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+
+	// the top row is red and the bottom row is blue
+	rows := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		rows.SetNRGBA(x, 0, red)
+		rows.SetNRGBA(x, 1, blue)
+	}
+	// the columns are white except the last one, which is black
+	columns := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	for x, v := range []uint8{255, 255, 255, 0} {
+		columns.SetNRGBA(x, 0, color.NRGBA{v, v, v, 255})
+	}
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+		dir  mgl32.Vec3
+		want color.NRGBA
+	}{
+		{"up", rows, mgl32.Vec3{0, 1, 0}, red},
+		{"down", rows, mgl32.Vec3{0, -2, 0}, blue},
+		{"horizon", rows, mgl32.Vec3{1, 0, 0}, color.NRGBA{128, 0, 128, 255}},
+		{"front", columns, mgl32.Vec3{0, 0, -1}, color.NRGBA{255, 255, 255, 255}},
+		// between the last and the first columns
+		{"back", columns, mgl32.Vec3{0, 0, 1}, color.NRGBA{128, 128, 128, 255}},
+		{"right", columns, mgl32.Vec3{1, 0, 0}, color.NRGBA{128, 128, 128, 255}},
+		{"left", columns, mgl32.Vec3{-1, 0, 0}, color.NRGBA{255, 255, 255, 255}},
+	}
+	for _, tt := range tests {
+		if got := sampleEquirect(tt.img, tt.dir); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
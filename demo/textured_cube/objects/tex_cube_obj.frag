@@ -1,10 +1,16 @@
 #version 330
 
 in vec2 fragTexCoord;
+in vec3 fragPos;
 out vec4 outputColor;
 
 uniform sampler2D tex;
 
+#include "sgl/shadow.glsl"
+
 void main() {
-    outputColor = texture(tex, fragTexCoord);
+    vec4 color = texture(tex, fragTexCoord);
+    // the object is not lit, so the shadow only darkens it
+    float s = shadowAt(fragPos, shadowBias);
+    outputColor = vec4(color.rgb * (1.0 - 0.5 * s), color.a);
 }
\ No newline at end of file
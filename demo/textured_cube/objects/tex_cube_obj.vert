@@ -4,6 +4,7 @@ layout(location = 0) in vec3 vert;
 layout(location = 1) in vec2 vertTexCoord;
 
 out vec2 fragTexCoord;
+out vec3 fragPos;
 
 uniform mat4 projection;
 uniform mat4 camera;
@@ -11,5 +12,6 @@ uniform mat4 model;
 
 void main() {
     fragTexCoord = vertTexCoord;
-    gl_Position = projection * camera * model * vec4(vert, 1);
+    fragPos = vec3(model * vec4(vert, 1));
+    gl_Position = projection * camera * vec4(fragPos, 1);
 }
\ No newline at end of file
@@ -0,0 +1,719 @@
+package sgl
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// GltfScene is a scene read from a glTF 2.0 (.gltf or .glb) file.
+type GltfScene struct {
+	// Nodes are the root nodes of the scene.
+	Nodes []*GltfNode
+}
+
+// GltfNode is a node of the node tree of a glTF scene.
+type GltfNode struct {
+	// Name is the name of the node. It's "node_<index>" if the node
+	// doesn't have a name.
+	Name string
+
+	// Model is the local transform of the node, which is relative to its
+	// parent node. It's made of the matrix or the translation, rotation
+	// and scale (TRS) of the node.
+	Model mgl32.Mat4
+
+	// Meshes are the triangulated primitives of the mesh of the node.
+	Meshes []GltfMesh
+
+	// Children are the child nodes.
+	Children []*GltfNode
+}
+
+// GltfMesh is a triangulated primitive of a glTF mesh.
+// Indexed primitives are expanded, so every three vertices form a triangle.
+type GltfMesh struct {
+	// Name is the name of the glTF mesh.
+	Name string
+
+	// Vertices uses 3 float32 values (X, Y, Z) to represent a vertex,
+	// so it could be used by SetVertices() directly.
+	Vertices []float32
+
+	// Normals uses 3 float32 values (NX, NY, NZ) for each vertex.
+	// If the primitive doesn't have normals, they're computed from the
+	// counter-clockwise winding order.
+	Normals []float32
+
+	// TexCoords uses 2 float32 values (U, V) for each vertex.
+	// It's nil if the primitive doesn't have texture coordinates.
+	TexCoords []float32
+
+	// BaseColor is the base color factor (RGBA) of the PBR material.
+	// It's the only material property that is read, textures and the
+	// metallic-roughness factors are ignored.
+	BaseColor mgl32.Vec4
+}
+
+// VerticesWithNormal returns the vertex array that contains 6 float32 values
+// per vertex: x, y, z, nx, ny, nz, which could be used by
+// SimpleObj.SetVerticesWithNormal().
+func (m *GltfMesh) VerticesWithNormal() []float32 {
+	vertices := make([]float32, 0, len(m.Vertices)*2)
+	for i := 0; i < len(m.Vertices); i += 3 {
+		vertices = append(vertices, m.Vertices[i:i+3]...)
+		vertices = append(vertices, m.Normals[i:i+3]...)
+	}
+	return vertices
+}
+
+// ReadGltfFile reads a glTF 2.0 file. Both the JSON (.gltf) and the binary
+// (.glb) formats are supported, and the external buffers are
+// looked up relative to the directory of the file.
+func ReadGltfFile(file string) (*GltfScene, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	scene, err := readGltf(data, filepath.Dir(file))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", file, err)
+	}
+	return scene, nil
+}
+
+// ReadGltf reads glTF 2.0 data (JSON or binary) from r.
+// The external buffers are looked up relative to dir.
+func ReadGltf(r io.Reader, dir string) (*GltfScene, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return readGltf(data, dir)
+}
+
+// NewGltfGroup returns a Group whose sub-groups follow the node tree
+// of the scene. Each node becomes a Group named after the node (with the
+// index among its siblings appended if the name is taken) whose group
+// model is the node's model, and each mesh of the node becomes a SimpleObj whose color
+// is the base color factor of the mesh. All the objects share the same program.
+func NewGltfGroup(scene *GltfScene, vp *Viewpoint, ls *LightSrc, mt *Material) Group {
+	g := NewGroup()
+	program := makeBuiltinProgram(getSimpleObjVS(), getSimpleObjFS())
+	g.programs = append(g.programs, program)
+	for i, node := range scene.Nodes {
+		child := newGltfNodeGroup(node, program, vp, ls, mt)
+		g.AddGroup(gltfGroupName(&g, node.Name, i), &child)
+	}
+	return g
+}
+
+func newGltfNodeGroup(
+	node *GltfNode,
+	program uint32,
+	vp *Viewpoint,
+	ls *LightSrc,
+	mt *Material,
+) Group {
+	g := NewGroup()
+	g.SetGroupModel(node.Model)
+	for i := range node.Meshes {
+		mesh := &node.Meshes[i]
+		obj := &SimpleObj{}
+		obj.SetProgram(program)
+		obj.SetProgVar(SimpleObjVar{
+			Red:   mesh.BaseColor[0],
+			Green: mesh.BaseColor[1],
+			Blue:  mesh.BaseColor[2],
+			Vp:    vp,
+			Ls:    ls,
+			Mt:    mt,
+		})
+		vertices := mesh.VerticesWithNormal()
+		obj.SetVerticesWithNormal(&vertices)
+		obj.SetModel(mgl32.Ident4())
+		g.AddObject(fmt.Sprintf("%v_%v", mesh.Name, i), obj)
+	}
+	for i, node := range node.Children {
+		child := newGltfNodeGroup(node, program, vp, ls, mt)
+		g.AddGroup(gltfGroupName(&g, node.Name, i), &child)
+	}
+	return g
+}
+
+// gltfGroupName returns the name of the i-th child group of g. glTF allows
+// sibling nodes with the same name, so the index is appended to the name
+// if it's taken, e.g. "wheel" and "wheel_1".
+func gltfGroupName(g *Group, name string, i int) string {
+	if _, ok := g.groups[name]; !ok {
+		return name
+	}
+	for {
+		unique := fmt.Sprintf("%v_%v", name, i)
+		if _, ok := g.groups[unique]; !ok {
+			return unique
+		}
+		i++
+	}
+}
+
+// glTF JSON structs, only the properties used by sgl are decoded.
+type gltfDoc struct {
+	Scene       *int              `json:"scene"`
+	Scenes      []gltfSceneDef    `json:"scenes"`
+	Nodes       []gltfNodeDef     `json:"nodes"`
+	Meshes      []gltfMeshDef     `json:"meshes"`
+	Accessors   []gltfAccessor    `json:"accessors"`
+	BufferViews []gltfBufferView  `json:"bufferViews"`
+	Buffers     []gltfBuffer      `json:"buffers"`
+	Materials   []gltfMaterialDef `json:"materials"`
+}
+
+type gltfSceneDef struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNodeDef struct {
+	Name        string    `json:"name"`
+	Mesh        *int      `json:"mesh"`
+	Children    []int     `json:"children"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+}
+
+type gltfMeshDef struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"`
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfMaterialDef struct {
+	PbrMetallicRoughness *struct {
+		BaseColorFactor []float32 `json:"baseColorFactor"`
+	} `json:"pbrMetallicRoughness"`
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+)
+
+// glTF primitive modes
+const (
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+// gltfTypeSizes maps the accessor types to the numbers of components.
+var gltfTypeSizes = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+// gltfReader keeps the decoded document and the loaded buffers.
+type gltfReader struct {
+	doc     gltfDoc
+	dir     string
+	buffers [][]byte
+}
+
+func readGltf(data []byte, dir string) (*GltfScene, error) {
+	r := &gltfReader{dir: dir}
+	jsonData := data
+	var bin []byte
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data[0:4]) == glbMagic {
+		var err error
+		jsonData, bin, err = readGlbChunks(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(jsonData, &r.doc); err != nil {
+		return nil, err
+	}
+	if err := r.loadBuffers(bin); err != nil {
+		return nil, err
+	}
+
+	roots := []int{}
+	if len(r.doc.Scenes) > 0 {
+		idx := 0
+		if r.doc.Scene != nil {
+			idx = *r.doc.Scene
+		}
+		if idx < 0 || idx >= len(r.doc.Scenes) {
+			return nil, fmt.Errorf("scene %v doesn't exist", idx)
+		}
+		roots = r.doc.Scenes[idx].Nodes
+	} else {
+		// without scenes, every node that is not a child is a root node
+		isChild := make([]bool, len(r.doc.Nodes))
+		for _, node := range r.doc.Nodes {
+			for _, c := range node.Children {
+				if c >= 0 && c < len(isChild) {
+					isChild[c] = true
+				}
+			}
+		}
+		for i := range r.doc.Nodes {
+			if !isChild[i] {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	scene := &GltfScene{}
+	visited := make([]bool, len(r.doc.Nodes))
+	for _, idx := range roots {
+		node, err := r.readNode(idx, visited)
+		if err != nil {
+			return nil, err
+		}
+		scene.Nodes = append(scene.Nodes, node)
+	}
+	return scene, nil
+}
+
+// readGlbChunks splits the binary glTF data into the JSON chunk
+// and the optional BIN chunk.
+func readGlbChunks(data []byte) ([]byte, []byte, error) {
+	version := binary.LittleEndian.Uint32(data[4:8])
+	if version != 2 {
+		return nil, nil, fmt.Errorf("glb version %v is not supported", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:12]))
+	if length > len(data) {
+		return nil, nil, errors.New("glb data is truncated")
+	}
+	var jsonData, bin []byte
+	for off := 12; off+8 <= length; {
+		chunkLen := int(binary.LittleEndian.Uint32(data[off : off+4]))
+		chunkType := binary.LittleEndian.Uint32(data[off+4 : off+8])
+		off += 8
+		if chunkLen < 0 || off+chunkLen > length {
+			return nil, nil, errors.New("glb chunk is truncated")
+		}
+		switch chunkType {
+		case glbChunkJSON:
+			if jsonData == nil {
+				jsonData = data[off : off+chunkLen]
+			}
+		case glbChunkBIN:
+			if bin == nil {
+				bin = data[off : off+chunkLen]
+			}
+		}
+		off += chunkLen
+	}
+	if jsonData == nil {
+		return nil, nil, errors.New("glb doesn't have a JSON chunk")
+	}
+	return jsonData, bin, nil
+}
+
+// loadBuffers loads the buffers from data URIs, external files or
+// the BIN chunk of a binary glTF.
+func (r *gltfReader) loadBuffers(bin []byte) error {
+	r.buffers = make([][]byte, len(r.doc.Buffers))
+	for i, buf := range r.doc.Buffers {
+		var data []byte
+		var err error
+		if buf.URI == "" {
+			if i != 0 || bin == nil {
+				return fmt.Errorf("buffer %v doesn't have data", i)
+			}
+			data = bin
+		} else {
+			data, err = r.loadURI(buf.URI)
+			if err != nil {
+				return err
+			}
+		}
+		if len(data) < buf.ByteLength {
+			return fmt.Errorf("buffer %v is truncated", i)
+		}
+		r.buffers[i] = data
+	}
+	return nil
+}
+
+// loadURI loads the data of a data URI or an external file.
+func (r *gltfReader) loadURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.Index(uri, ",")
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("unsupported data uri %.32q", uri)
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(r.dir, filepath.FromSlash(path)))
+}
+
+// bufferView returns the bytes of a buffer view.
+func (r *gltfReader) bufferView(idx int) ([]byte, error) {
+	if idx < 0 || idx >= len(r.doc.BufferViews) {
+		return nil, fmt.Errorf("buffer view %v doesn't exist", idx)
+	}
+	bv := r.doc.BufferViews[idx]
+	if bv.Buffer < 0 || bv.Buffer >= len(r.buffers) {
+		return nil, fmt.Errorf("buffer %v doesn't exist", bv.Buffer)
+	}
+	buf := r.buffers[bv.Buffer]
+	if bv.ByteOffset < 0 || bv.ByteLength < 0 || bv.ByteOffset+bv.ByteLength > len(buf) {
+		return nil, fmt.Errorf("buffer view %v is out of range", idx)
+	}
+	return buf[bv.ByteOffset : bv.ByteOffset+bv.ByteLength], nil
+}
+
+// readAccessor reads the values of an accessor as float64, which can keep
+// both float32 and uint32 values exactly. It returns the values and the
+// number of components per element.
+func (r *gltfReader) readAccessor(idx int) ([]float64, int, error) {
+	if idx < 0 || idx >= len(r.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %v doesn't exist", idx)
+	}
+	acc := r.doc.Accessors[idx]
+	if len(acc.Sparse) > 0 {
+		return nil, 0, fmt.Errorf("accessor %v: sparse accessors are not supported", idx)
+	}
+	comps, ok := gltfTypeSizes[acc.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %v: unknown type %v", idx, acc.Type)
+	}
+	compSize := gltfComponentSize(acc.ComponentType)
+	if compSize == 0 {
+		return nil, 0, fmt.Errorf("accessor %v: unknown component type %v", idx, acc.ComponentType)
+	}
+	if acc.Count < 0 || acc.Count > math.MaxInt32/comps {
+		return nil, 0, fmt.Errorf("accessor %v: invalid count %v", idx, acc.Count)
+	}
+	if acc.BufferView == nil {
+		// accessors without buffer views are initialized with zeros
+		return make([]float64, acc.Count*comps), comps, nil
+	}
+	data, err := r.bufferView(*acc.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+	stride := r.doc.BufferViews[*acc.BufferView].ByteStride
+	if stride == 0 {
+		stride = comps * compSize
+	}
+	// the last element should end inside the buffer view, which is checked
+	// before allocating the values for a corrupt count
+	if acc.ByteOffset < 0 || stride < 0 || acc.Count > len(data) ||
+		acc.Count > 0 && acc.ByteOffset+(acc.Count-1)*stride+comps*compSize > len(data) {
+		return nil, 0, fmt.Errorf("accessor %v is out of range", idx)
+	}
+	values := make([]float64, acc.Count*comps)
+	for i := 0; i < acc.Count; i++ {
+		for c := 0; c < comps; c++ {
+			off := acc.ByteOffset + i*stride + c*compSize
+			values[i*comps+c] = gltfComponent(data[off:], acc.ComponentType, acc.Normalized)
+		}
+	}
+	return values, comps, nil
+}
+
+func gltfComponentSize(componentType int) int {
+	switch componentType {
+	case 5120, 5121: // BYTE, UNSIGNED_BYTE
+		return 1
+	case 5122, 5123: // SHORT, UNSIGNED_SHORT
+		return 2
+	case 5125, 5126: // UNSIGNED_INT, FLOAT
+		return 4
+	}
+	return 0
+}
+
+// gltfComponent decodes a component. Normalized integers are mapped to
+// [0, 1] (unsigned) or [-1, 1] (signed).
+func gltfComponent(b []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case 5120:
+		v := float64(int8(b[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case 5121:
+		v := float64(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case 5122:
+		v := float64(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case 5123:
+		v := float64(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case 5125:
+		return float64(binary.LittleEndian.Uint32(b))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+}
+
+// readNode reads a node and its children. visited is used to reject
+// node trees that contain cycles.
+func (r *gltfReader) readNode(idx int, visited []bool) (*GltfNode, error) {
+	if idx < 0 || idx >= len(r.doc.Nodes) {
+		return nil, fmt.Errorf("node %v doesn't exist", idx)
+	}
+	if visited[idx] {
+		return nil, fmt.Errorf("node %v is used more than once", idx)
+	}
+	visited[idx] = true
+	def := r.doc.Nodes[idx]
+
+	node := &GltfNode{Name: def.Name, Model: gltfNodeModel(def)}
+	if node.Name == "" {
+		node.Name = fmt.Sprintf("node_%v", idx)
+	}
+	if def.Mesh != nil {
+		meshes, err := r.readMesh(*def.Mesh)
+		if err != nil {
+			return nil, err
+		}
+		node.Meshes = meshes
+	}
+	for _, c := range def.Children {
+		child, err := r.readNode(c, visited)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
+
+// gltfNodeModel returns the local transform of a node, which is either
+// its matrix (column-major, same as mgl32.Mat4) or T * R * S.
+func gltfNodeModel(def gltfNodeDef) mgl32.Mat4 {
+	if len(def.Matrix) == 16 {
+		m := mgl32.Mat4{}
+		copy(m[:], def.Matrix)
+		return m
+	}
+	model := mgl32.Ident4()
+	if len(def.Translation) == 3 {
+		model = mgl32.Translate3D(def.Translation[0], def.Translation[1], def.Translation[2])
+	}
+	if len(def.Rotation) == 4 {
+		q := mgl32.Quat{
+			W: def.Rotation[3],
+			V: mgl32.Vec3{def.Rotation[0], def.Rotation[1], def.Rotation[2]},
+		}
+		model = model.Mul4(q.Normalize().Mat4())
+	}
+	if len(def.Scale) == 3 {
+		model = model.Mul4(mgl32.Scale3D(def.Scale[0], def.Scale[1], def.Scale[2]))
+	}
+	return model
+}
+
+// readMesh reads the triangle primitives of a mesh. Points and lines
+// can't be rendered as triangles, so they are skipped.
+func (r *gltfReader) readMesh(idx int) ([]GltfMesh, error) {
+	if idx < 0 || idx >= len(r.doc.Meshes) {
+		return nil, fmt.Errorf("mesh %v doesn't exist", idx)
+	}
+	def := r.doc.Meshes[idx]
+	name := def.Name
+	if name == "" {
+		name = fmt.Sprintf("mesh_%v", idx)
+	}
+	meshes := []GltfMesh{}
+	for _, prim := range def.Primitives {
+		mode := gltfTriangles
+		if prim.Mode != nil {
+			mode = *prim.Mode
+		}
+		if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+			continue
+		}
+		mesh, err := r.readPrimitive(prim, mode)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %v: %w", idx, err)
+		}
+		mesh.Name = name
+		meshes = append(meshes, mesh)
+	}
+	return meshes, nil
+}
+
+func (r *gltfReader) readPrimitive(prim gltfPrimitive, mode int) (GltfMesh, error) {
+	mesh := GltfMesh{BaseColor: mgl32.Vec4{1, 1, 1, 1}}
+
+	posIdx, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return mesh, errors.New("primitive doesn't have POSITION")
+	}
+	positions, _, err := r.readAccessor(posIdx)
+	if err != nil {
+		return mesh, err
+	}
+	vertNum := len(positions) / 3
+	var normals, texCoords []float64
+	if idx, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, _, err = r.readAccessor(idx); err != nil {
+			return mesh, err
+		}
+	}
+	if idx, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		if texCoords, _, err = r.readAccessor(idx); err != nil {
+			return mesh, err
+		}
+	}
+
+	// indices of the vertices, every three indices form a triangle
+	indices := []int{}
+	if prim.Indices != nil {
+		values, _, err := r.readAccessor(*prim.Indices)
+		if err != nil {
+			return mesh, err
+		}
+		for _, v := range values {
+			indices = append(indices, int(v))
+		}
+	} else {
+		for i := 0; i < vertNum; i++ {
+			indices = append(indices, i)
+		}
+	}
+	indices = gltfTriangleIndices(indices, mode)
+
+	for _, i := range indices {
+		if i < 0 || i >= vertNum {
+			return mesh, fmt.Errorf("vertex index %v is out of range", i)
+		}
+		mesh.Vertices = append(mesh.Vertices,
+			float32(positions[i*3]), float32(positions[i*3+1]), float32(positions[i*3+2]))
+		if len(normals) >= vertNum*3 {
+			mesh.Normals = append(mesh.Normals,
+				float32(normals[i*3]), float32(normals[i*3+1]), float32(normals[i*3+2]))
+		}
+		if len(texCoords) >= vertNum*2 {
+			mesh.TexCoords = append(mesh.TexCoords,
+				float32(texCoords[i*2]), float32(texCoords[i*2+1]))
+		}
+	}
+	if len(mesh.Normals) != len(mesh.Vertices) {
+		// flat normals, as the glTF spec requires when normals are not defined
+		mesh.Normals = make([]float32, 0, len(mesh.Vertices))
+		for i := 0; i+8 < len(mesh.Vertices); i += 9 {
+			normal := faceNormal(
+				mgl32.Vec3{mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2]},
+				mgl32.Vec3{mesh.Vertices[i+3], mesh.Vertices[i+4], mesh.Vertices[i+5]},
+				mgl32.Vec3{mesh.Vertices[i+6], mesh.Vertices[i+7], mesh.Vertices[i+8]},
+			)
+			for j := 0; j < 3; j++ {
+				mesh.Normals = append(mesh.Normals, normal[0], normal[1], normal[2])
+			}
+		}
+	}
+
+	if prim.Material != nil {
+		if err := r.applyMaterial(&mesh, *prim.Material); err != nil {
+			return mesh, err
+		}
+	}
+	return mesh, nil
+}
+
+// gltfTriangleIndices converts the indices of triangle strips and
+// triangle fans into the indices of separate triangles.
+func gltfTriangleIndices(indices []int, mode int) []int {
+	triangles := []int{}
+	switch mode {
+	case gltfTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				triangles = append(triangles, indices[i], indices[i+1], indices[i+2])
+			} else {
+				triangles = append(triangles, indices[i+1], indices[i], indices[i+2])
+			}
+		}
+	case gltfTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			triangles = append(triangles, indices[0], indices[i], indices[i+1])
+		}
+	default:
+		triangles = indices[:len(indices)/3*3]
+	}
+	return triangles
+}
+
+// applyMaterial copies the base color factor of a material to the mesh.
+func (r *gltfReader) applyMaterial(mesh *GltfMesh, idx int) error {
+	if idx < 0 || idx >= len(r.doc.Materials) {
+		return fmt.Errorf("material %v doesn't exist", idx)
+	}
+	pbr := r.doc.Materials[idx].PbrMetallicRoughness
+	if pbr == nil {
+		return nil
+	}
+	if len(pbr.BaseColorFactor) == 4 {
+		copy(mesh.BaseColor[:], pbr.BaseColorFactor)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package sgl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// gltfTriangleBuffer is a buffer of 3 VEC3 float positions followed by
+// 3 unsigned short indices.
+func gltfTriangleBuffer() []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, []float32{
+		0, 0, 0,
+		1, 0, 0,
+		0, 1, 0,
+	})
+	binary.Write(buf, binary.LittleEndian, []uint16{0, 1, 2})
+	return buf.Bytes()
+}
+
+// gltfTriangleDoc returns a glTF document of a triangle. nodes and
+// accessor replace the nodes and the position accessor if they're not "".
+func gltfTriangleDoc(nodes, accessor string) string {
+	if nodes == "" {
+		nodes = `[{"name": "tri", "mesh": 0}]`
+	}
+	if accessor == "" {
+		accessor = `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`
+	}
+	return fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"nodes": %v,
+		"meshes": [{"name": "m", "primitives": [
+			{"attributes": {"POSITION": 0}, "indices": 1, "material": 0}
+		]}],
+		"materials": [{"pbrMetallicRoughness": {
+			"baseColorFactor": [1, 0.5, 0, 1], "metallicFactor": 0
+		}}],
+		"accessors": [
+			%v,
+			{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}
+		],
+		"bufferViews": [
+			{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+			{"buffer": 0, "byteOffset": 36, "byteLength": 6}
+		],
+		"buffers": [{"byteLength": 42, "uri": "data:application/octet-stream;base64,%v"}]
+	}`, nodes, accessor, base64.StdEncoding.EncodeToString(gltfTriangleBuffer()))
+}
+
+func TestReadGltf(t *testing.T) {
+	scene, err := ReadGltf(strings.NewReader(gltfTriangleDoc("", "")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.Nodes) != 1 || len(scene.Nodes[0].Meshes) != 1 {
+		t.Fatalf("got %v nodes", len(scene.Nodes))
+	}
+	mesh := scene.Nodes[0].Meshes[0]
+	if mesh.Name != "m" {
+		t.Errorf("got mesh name %q, want \"m\"", mesh.Name)
+	}
+	wantVertices := []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}
+	if !equalFloats(mesh.Vertices, wantVertices) {
+		t.Errorf("got vertices %v, want %v", mesh.Vertices, wantVertices)
+	}
+	// flat normals from the winding order
+	wantNormals := []float32{0, 0, 1, 0, 0, 1, 0, 0, 1}
+	if !equalFloats(mesh.Normals, wantNormals) {
+		t.Errorf("got normals %v, want %v", mesh.Normals, wantNormals)
+	}
+	if mesh.BaseColor != (mgl32.Vec4{1, 0.5, 0, 1}) {
+		t.Errorf("got base color %v, want [1 0.5 0 1]", mesh.BaseColor)
+	}
+}
+
+func TestReadGlb(t *testing.T) {
+	bin := gltfTriangleBuffer()
+	doc := strings.Replace(gltfTriangleDoc("", ""), `, "uri": "data:application/octet-stream;base64,`+
+		base64.StdEncoding.EncodeToString(bin)+`"`, "", 1)
+	jsonChunk := []byte(doc)
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, []uint32{
+		glbMagic, 2, uint32(12 + 8 + len(jsonChunk) + 8 + len(bin)),
+		uint32(len(jsonChunk)), glbChunkJSON,
+	})
+	buf.Write(jsonChunk)
+	binary.Write(buf, binary.LittleEndian, []uint32{uint32(len(bin)), glbChunkBIN})
+	buf.Write(bin)
+	data := buf.Bytes()
+
+	scene, err := ReadGltf(bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.Nodes) != 1 || len(scene.Nodes[0].Meshes[0].Vertices) != 9 {
+		t.Fatalf("got scene %+v", scene)
+	}
+
+	if _, err := ReadGltf(bytes.NewReader(data[:len(data)-4]), ""); err == nil {
+		t.Error("ReadGltf() accepted truncated glb data")
+	}
+}
+
+func TestReadGltfNodes(t *testing.T) {
+	nodes := `[
+		{"name": "root", "children": [1, 2], "translation": [1, 2, 3]},
+		{"name": "wheel", "mesh": 0, "scale": [2, 2, 2]},
+		{"name": "wheel", "mesh": 0, "matrix": [1,0,0,0, 0,1,0,0, 0,0,1,0, 4,5,6,1]},
+		{"mesh": 0}
+	]`
+	scene, err := ReadGltf(strings.NewReader(gltfTriangleDoc(nodes, "")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.Nodes) != 2 {
+		t.Fatalf("got %v root nodes, want 2", len(scene.Nodes))
+	}
+	root := scene.Nodes[0]
+	if root.Model != mgl32.Translate3D(1, 2, 3) || len(root.Children) != 2 {
+		t.Errorf("got root %+v", root)
+	}
+	if root.Children[0].Model != mgl32.Scale3D(2, 2, 2) {
+		t.Errorf("got scale model %v", root.Children[0].Model)
+	}
+	if root.Children[1].Model != mgl32.Translate3D(4, 5, 6) {
+		t.Errorf("got matrix model %v", root.Children[1].Model)
+	}
+	if scene.Nodes[1].Name != "node_3" {
+		t.Errorf("got unnamed node %q, want \"node_3\"", scene.Nodes[1].Name)
+	}
+}
+
+func TestReadGltfErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    string
+		accessor string
+	}{
+		{
+			name:     "negative count",
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": -1, "type": "VEC3"}`,
+		},
+		{
+			name:     "huge count",
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`,
+		},
+		{
+			name:     "huge count without buffer view",
+			accessor: `{"componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`,
+		},
+		{
+			name:     "count out of range",
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"}`,
+		},
+		{
+			name:     "offset out of range",
+			accessor: `{"bufferView": 0, "byteOffset": 4, "componentType": 5126, "count": 3, "type": "VEC3"}`,
+		},
+		{
+			name:     "negative offset",
+			accessor: `{"bufferView": 0, "byteOffset": -4, "componentType": 5126, "count": 3, "type": "VEC3"}`,
+		},
+		{
+			name:     "unknown type",
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC5"}`,
+		},
+		{
+			name:     "unknown component type",
+			accessor: `{"bufferView": 0, "componentType": 1, "count": 3, "type": "VEC3"}`,
+		},
+		{
+			name:     "missing buffer view",
+			accessor: `{"bufferView": 5, "componentType": 5126, "count": 3, "type": "VEC3"}`,
+		},
+		{
+			name:  "cycle",
+			nodes: `[{"mesh": 0, "children": [1]}, {"children": [0]}]`,
+		},
+		{
+			name:  "missing mesh",
+			nodes: `[{"mesh": 3}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := gltfTriangleDoc(tt.nodes, tt.accessor)
+			if tt.name == "cycle" {
+				// without scenes every node is a child, so use a scene
+				doc = strings.Replace(doc, `"nodes":`, `"scenes": [{"nodes": [0]}], "nodes":`, 1)
+			}
+			scene, err := ReadGltf(strings.NewReader(doc), "")
+			if err == nil {
+				t.Fatalf("got scene %+v, want an error", scene)
+			}
+		})
+	}
+}
+
+func TestGltfTriangleIndices(t *testing.T) {
+	tests := []struct {
+		name    string
+		indices []int
+		mode    int
+		want    []int
+	}{
+		{"triangles", []int{0, 1, 2, 3, 4}, gltfTriangles, []int{0, 1, 2}},
+		{"strip", []int{0, 1, 2, 3}, gltfTriangleStrip, []int{0, 1, 2, 2, 1, 3}},
+		{"fan", []int{0, 1, 2, 3}, gltfTriangleFan, []int{0, 1, 2, 0, 2, 3}},
+		{"short strip", []int{0, 1}, gltfTriangleStrip, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gltfTriangleIndices(tt.indices, tt.mode)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGltfGroupName(t *testing.T) {
+	g := NewGroup()
+	names := []string{}
+	for i, name := range []string{"wheel", "wheel", "body", "wheel", "wheel_3"} {
+		unique := gltfGroupName(&g, name, i)
+		child := NewGroup()
+		g.AddGroup(unique, &child)
+		names = append(names, unique)
+	}
+	want := []string{"wheel", "wheel_1", "body", "wheel_3", "wheel_3_4"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+	if len(g.groups) != len(want) {
+		t.Errorf("got %v groups, want %v", len(g.groups), len(want))
+	}
+}
@@ -1,19 +1,26 @@
 package sgl
 
 import (
+	"github.com/go-gl/gl/all-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 type Group struct {
 	objects      map[string]Object
 	objectModels map[string]mgl32.Mat4
+	groups       map[string]*Group
 	groupModel   mgl32.Mat4
+
+	// programs are the programs shared by the objects, which are
+	// deleted together with the group.
+	programs []uint32
 }
 
 func NewGroup() Group {
 	g := Group{}
 	g.objects = map[string]Object{}
 	g.objectModels = map[string]mgl32.Mat4{}
+	g.groups = map[string]*Group{}
 	g.groupModel = mgl32.Ident4()
 	return g
 }
@@ -23,6 +30,18 @@ func (g *Group) AddObject(name string, obj Object) {
 	g.objectModels[name] = obj.GetModel()
 }
 
+// AddGroup adds a sub-group to the group. The group model of the sub-group
+// is relative to the group model of its parent, so the sub-group moves
+// together with its parent.
+func (g *Group) AddGroup(name string, group *Group) {
+	g.groups[name] = group
+}
+
+// GetGroup gets the sub-group by its name.
+func (g *Group) GetGroup(name string) *Group {
+	return g.groups[name]
+}
+
 func (g *Group) SetObjectModel(name string, newModel mgl32.Mat4) {
 	g.objectModels[name] = newModel
 }
@@ -32,9 +51,61 @@ func (g *Group) SetGroupModel(newModel mgl32.Mat4) {
 }
 
 func (g *Group) Render() {
+	g.render(mgl32.Ident4())
+}
+
+// render renders the objects and the sub-groups of the group
+// with the model of the parent group.
+func (g *Group) render(parentModel mgl32.Mat4) {
+	groupModel := parentModel.Mul4(g.groupModel)
 	for name, obj := range g.objects {
-		model := g.groupModel.Mul4(g.objectModels[name])
+		model := groupModel.Mul4(g.objectModels[name])
 		obj.SetModel(model)
 		obj.Render()
 	}
+	for _, group := range g.groups {
+		group.render(groupModel)
+	}
+}
+
+// DrawShadow draws the objects and the sub-groups of the group into
+// a ShadowMap, so the group casts shadows as a whole.
+func (g *Group) DrawShadow(modelLocation int32) {
+	g.drawShadow(modelLocation, mgl32.Ident4())
+}
+
+// drawShadow draws the shadow casters of the group with the model of the
+// parent group.
+func (g *Group) drawShadow(modelLocation int32, parentModel mgl32.Mat4) {
+	groupModel := parentModel.Mul4(g.groupModel)
+	for name, obj := range g.objects {
+		caster, ok := obj.(ShadowCaster)
+		if !ok {
+			continue
+		}
+		obj.SetModel(groupModel.Mul4(g.objectModels[name]))
+		caster.DrawShadow(modelLocation)
+	}
+	for _, group := range g.groups {
+		group.drawShadow(modelLocation, groupModel)
+	}
+}
+
+// Delete deletes all the objects and the sub-groups of the group, as well as
+// the programs shared by the objects which are created with the group
+// (e.g. by NewObjGroup()).
+func (g *Group) Delete() {
+	for _, obj := range g.objects {
+		obj.Delete()
+	}
+	for _, group := range g.groups {
+		group.Delete()
+	}
+	for i := range g.programs {
+		gl.DeleteProgram(g.programs[i])
+	}
+	g.objects = map[string]Object{}
+	g.objectModels = map[string]mgl32.Mat4{}
+	g.groups = map[string]*Group{}
+	g.programs = nil
 }
@@ -1,11 +1,16 @@
 package sgl
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
 
 type LightSrc struct {
-	Pos       mgl32.Vec3
-	Color     mgl32.Vec3
-	Intensity float32
+	Pos       mgl32.Vec3 `sgl:"lightPos"`
+	Color     mgl32.Vec3 `sgl:"lightColor"`
+	Intensity float32    `sgl:"lightIntensity"`
 }
 
 func NewLightSrc() LightSrc {
@@ -15,3 +20,134 @@ func NewLightSrc() LightSrc {
 	ls.Intensity = 1
 	return ls
 }
+
+// Light returns the light source as a point light without attenuation.
+func (ls LightSrc) Light() Light {
+	return NewPointLight(ls.Pos, ls.Color, ls.Intensity)
+}
+
+// LightType is the type of a Light.
+type LightType int32
+
+const (
+	// PointLight emits the light from Pos in all directions.
+	PointLight LightType = iota
+
+	// DirectionalLight emits parallel light in Direction, like the sun.
+	DirectionalLight
+
+	// SpotLight emits the light from Pos in a cone around Direction.
+	SpotLight
+)
+
+// Light is a directional, point or spot light. The order of the fields is
+// the layout of the Light struct in "sgl/light_set.glsl".
+type Light struct {
+	Type LightType `sgl:"type"`
+
+	// Pos is the position of a point light or a spot light.
+	Pos mgl32.Vec3 `sgl:"pos"`
+
+	// Direction is the direction of a directional light or a spot light.
+	Direction mgl32.Vec3 `sgl:"direction"`
+
+	Color     mgl32.Vec3 `sgl:"color"`
+	Intensity float32    `sgl:"intensity"`
+
+	// Constant, Linear and Quadratic are the attenuation of a point light
+	// or a spot light: 1 / (Constant + Linear*d + Quadratic*d*d), where d is
+	// the distance to the light.
+	Constant  float32 `sgl:"constant"`
+	Linear    float32 `sgl:"linear"`
+	Quadratic float32 `sgl:"quadratic"`
+
+	// CutOff and OuterCutOff are the cosines of the inner and the outer
+	// angles of the cone of a spot light. The light fades out between them.
+	CutOff      float32 `sgl:"cutOff"`
+	OuterCutOff float32 `sgl:"outerCutOff"`
+}
+
+// NewDirectionalLight returns a directional light.
+func NewDirectionalLight(direction mgl32.Vec3, color mgl32.Vec3, intensity float32) Light {
+	return Light{
+		Type:      DirectionalLight,
+		Direction: direction,
+		Color:     color,
+		Intensity: intensity,
+	}
+}
+
+// NewPointLight returns a point light without attenuation.
+// Set Constant, Linear and Quadratic to make it fade with the distance.
+func NewPointLight(pos mgl32.Vec3, color mgl32.Vec3, intensity float32) Light {
+	return Light{
+		Type:      PointLight,
+		Pos:       pos,
+		Color:     color,
+		Intensity: intensity,
+		Constant:  1,
+	}
+}
+
+// NewSpotLight returns a spot light without attenuation. cutOff and
+// outerCutOff are the inner and the outer angles of the cone in radians.
+func NewSpotLight(
+	pos mgl32.Vec3, direction mgl32.Vec3, color mgl32.Vec3, intensity float32,
+	cutOff float32, outerCutOff float32,
+) Light {
+	return Light{
+		Type:        SpotLight,
+		Pos:         pos,
+		Direction:   direction,
+		Color:       color,
+		Intensity:   intensity,
+		Constant:    1,
+		CutOff:      float32(math.Cos(float64(cutOff))),
+		OuterCutOff: float32(math.Cos(float64(outerCutOff))),
+	}
+}
+
+// updateLightSrcSet sets set to the light set of ls, or to an empty set if
+// ls is nil, and updates the shared buffer of LightSet. It lights up the
+// objects without a LightSet, which would be lit by the LightSet of the
+// object rendered before otherwise.
+func updateLightSrcSet(set *LightSet, ls *LightSrc) {
+	if ls != nil {
+		*set = NewLightSet(ls.Light())
+	} else {
+		*set = LightSet{}
+	}
+	updateSharedUniformBuffer(set)
+}
+
+// MaxLights is the maximal number of the lights in a LightSet.
+const MaxLights = 8
+
+// LightSet is the set of lights that light up an object together,
+// e.g. a key light, a fill light and the sun.
+type LightSet struct {
+	Lights [MaxLights]Light `sgl:"lights"`
+	Count  int32            `sgl:"lightCount"`
+}
+
+// NewLightSet returns a LightSet of the lights.
+// It panics if there are more than MaxLights lights.
+func NewLightSet(lights ...Light) LightSet {
+	set := LightSet{}
+	for _, l := range lights {
+		if err := set.Add(l); err != nil {
+			panic(err)
+		}
+	}
+	return set
+}
+
+// Add adds a light to the set. It returns an error if the set is full.
+func (s *LightSet) Add(light Light) error {
+	if s.Count >= MaxLights {
+		return fmt.Errorf("light set is full (%v lights)", MaxLights)
+	}
+	s.Lights[s.Count] = light
+	s.Count++
+	return nil
+}
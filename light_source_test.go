@@ -0,0 +1,129 @@
+package sgl
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestNewLights(t *testing.T) {
+	tests := []struct {
+		name  string
+		light Light
+		want  Light
+	}{
+		{
+			name:  "directional",
+			light: NewDirectionalLight(mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 1, 1}, 0.5),
+			want: Light{
+				Type:      DirectionalLight,
+				Direction: mgl32.Vec3{0, -1, 0},
+				Color:     mgl32.Vec3{1, 1, 1},
+				Intensity: 0.5,
+			},
+		},
+		{
+			name:  "point",
+			light: NewPointLight(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{1, 0, 0}, 2),
+			want: Light{
+				Type:      PointLight,
+				Pos:       mgl32.Vec3{1, 2, 3},
+				Color:     mgl32.Vec3{1, 0, 0},
+				Intensity: 2,
+				Constant:  1,
+			},
+		},
+		{
+			name:  "spot",
+			light: NewSpotLight(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}, 1, 0, math.Pi/3),
+			want: Light{
+				Type:        SpotLight,
+				Pos:         mgl32.Vec3{1, 2, 3},
+				Direction:   mgl32.Vec3{0, 0, -1},
+				Color:       mgl32.Vec3{0, 1, 0},
+				Intensity:   1,
+				Constant:    1,
+				CutOff:      1,
+				OuterCutOff: 0.5,
+			},
+		},
+		{
+			name:  "light source",
+			light: LightSrc{Pos: mgl32.Vec3{4, 5, 6}, Color: mgl32.Vec3{1, 1, 0}, Intensity: 3}.Light(),
+			want: Light{
+				Type:      PointLight,
+				Pos:       mgl32.Vec3{4, 5, 6},
+				Color:     mgl32.Vec3{1, 1, 0},
+				Intensity: 3,
+				Constant:  1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := tt.light
+		// the cosines are not exact
+		if math.Abs(float64(got.OuterCutOff-tt.want.OuterCutOff)) < 1e-6 {
+			got.OuterCutOff = tt.want.OuterCutOff
+		}
+		if got != tt.want {
+			t.Errorf("%v: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLightSet(t *testing.T) {
+	sun := NewDirectionalLight(mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 1, 1}, 1)
+	key := NewPointLight(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{1, 0, 0}, 2)
+	set := NewLightSet(sun, key)
+	if set.Count != 2 || set.Lights[0] != sun || set.Lights[1] != key || set.Lights[2] != (Light{}) {
+		t.Errorf("got %+v", set)
+	}
+	for i := 2; i < MaxLights; i++ {
+		if err := set.Add(key); err != nil {
+			t.Fatalf("adding light %v: %v", i, err)
+		}
+	}
+	if err := set.Add(key); err == nil || set.Count != MaxLights {
+		t.Errorf("adding to a full set: got %v and %v lights", err, set.Count)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewLightSet() with too many lights doesn't panic")
+		}
+	}()
+	lights := make([]Light, MaxLights+1)
+	NewLightSet(lights...)
+}
+
+func TestLightSetLayout(t *testing.T) {
+	b, err := newUniformBufferLayout("LightSet", LightSetBinding, reflect.TypeOf(LightSet{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the Light struct takes 96 bytes in std140, see "sgl/light_set.glsl"
+	if len(b.data) != 784 {
+		t.Errorf("got size %v, want 784", len(b.data))
+	}
+	set := NewLightSet(
+		NewDirectionalLight(mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 1, 1}, 1),
+		NewSpotLight(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}, 0.5, 0, 0),
+	)
+	b.pack(&set)
+	got := std140Floats(b.data,
+		96+16, 96+20, 96+24, // lights[1].pos
+		96+32, 96+36, 96+40, // lights[1].direction
+		96+48, 96+52, 96+56, // lights[1].color
+		96+60, 96+64, 96+76, 96+80, // lights[1].intensity, constant, cutOff, outerCutOff
+	)
+	want := []float32{1, 2, 3, 0, 0, -1, 0, 1, 0, 0.5, 1, 1, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got packed light %v, want %v", got, want)
+	}
+	types := []int32{int32(b.data[0]), int32(b.data[96])}
+	if types[0] != int32(DirectionalLight) || types[1] != int32(SpotLight) || b.data[768] != 2 {
+		t.Errorf("got types %v and count %v", types, b.data[768])
+	}
+}
@@ -3,10 +3,14 @@ package sgl
 import "github.com/go-gl/mathgl/mgl32"
 
 type Material struct {
-	Ambient   mgl32.Vec3
-	Diffuse   mgl32.Vec3
-	Specular  mgl32.Vec3
-	Shininess float32
+	Ambient   mgl32.Vec3 `sgl:"materialAmbient"`
+	Diffuse   mgl32.Vec3 `sgl:"materialDiffuse"`
+	Specular  mgl32.Vec3 `sgl:"materialSpecular"`
+	Shininess float32    `sgl:"materialShininess"`
+
+	// Reflectivity is how much the object reflects the environment (see
+	// SimpleObjVar.Env), from 0 (not at all) to 1 (a mirror).
+	Reflectivity float32 `sgl:"materialReflectivity"`
 }
 
 func NewMaterial() Material {
@@ -17,3 +21,42 @@ func NewMaterial() Material {
 	m.Shininess = 8
 	return m
 }
+
+// PbrMaterial is a physically based material of the metallic-roughness
+// workflow, which is used by PbrObj. The values are multiplied by the
+// textures if they're not nil. The colors are in linear space, so AlbedoMap
+// and EmissiveMap should be sRGB textures (see TextureOptions.SRGB) if the
+// images are in sRGB, like most PNG and JPEG files.
+type PbrMaterial struct {
+	// Albedo is the base color of the surface.
+	Albedo mgl32.Vec3 `sgl:"albedo"`
+
+	// Metallic is 0 for dielectrics (e.g. plastic) and 1 for metals.
+	Metallic float32 `sgl:"metallic"`
+
+	// Roughness is from 0 (smooth like a mirror) to 1 (rough).
+	Roughness float32 `sgl:"roughness"`
+
+	// AO is the ambient occlusion, which darkens the ambient light in
+	// the creases.
+	AO float32 `sgl:"ao"`
+
+	// Emissive is the color emitted by the surface itself.
+	Emissive mgl32.Vec3 `sgl:"emissive"`
+
+	AlbedoMap    *Texture
+	MetallicMap  *Texture // red channel, e.g. TextureR
+	RoughnessMap *Texture // red channel, e.g. TextureR
+	AOMap        *Texture // red channel, e.g. TextureR
+	EmissiveMap  *Texture
+}
+
+// NewPbrMaterial returns a white, non-metallic and half-rough material.
+func NewPbrMaterial() PbrMaterial {
+	m := PbrMaterial{}
+	m.Albedo = mgl32.Vec3{1, 1, 1}
+	m.Metallic = 0
+	m.Roughness = 0.5
+	m.AO = 1
+	return m
+}
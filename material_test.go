@@ -0,0 +1,38 @@
+package sgl
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestNewPbrMaterial(t *testing.T) {
+	want := PbrMaterial{Albedo: mgl32.Vec3{1, 1, 1}, Roughness: 0.5, AO: 1}
+	if got := NewPbrMaterial(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPbrMaterialUniforms(t *testing.T) {
+	used := []string{"albedo", "metallic", "roughness", "ao", "emissive"}
+	fakeUniformLocations(t, used...)
+	// the material alone, the other fields of PbrObjVar are uniform blocks
+	b, err := NewUniformBinder(1, &struct{ Mt *PbrMaterial }{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations := b.Locations()
+	for i, name := range used {
+		if location, ok := locations[name]; !ok || location != int32(i) {
+			t.Errorf("got location %v of %v, want %v", location, name, i)
+		}
+	}
+	// the maps are bound by PbrObj, they're not uniforms
+	for name := range locations {
+		for _, m := range pbrMaps {
+			if name == m.sampler || name == m.flag {
+				t.Errorf("got uniform %v of the maps", name)
+			}
+		}
+	}
+}
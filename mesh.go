@@ -0,0 +1,248 @@
+package sgl
+
+import (
+	"math"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// IndexedMesh is a vertex array without duplicated vertices, and the indices
+// of the vertices that form the triangles. Every three indices form a triangle.
+type IndexedMesh struct {
+	// Vertices are the unique vertices of the mesh.
+	Vertices []float32
+
+	// Indices are the indices of the vertices (not the float32 values)
+	// that form the triangles.
+	Indices []uint32
+
+	// Stride is the number of float32 values per vertex.
+	Stride int
+}
+
+// WeldVertices deduplicates the vertices of a vertex array whose every three
+// vertices form a triangle, like the output of the STL readers, the shapes
+// and AddNormal(). stride is the number of float32 values per vertex, and two
+// vertices are merged only if all their values are equal, e.g. with stride 6
+// (x, y, z, nx, ny, nz) the vertices at the same position but with different
+// normals are kept separately.
+// The output could be used by SetVertices() and SetIndices().
+func WeldVertices(vertices []float32, stride int) IndexedMesh {
+	mesh := IndexedMesh{Stride: stride}
+	if stride <= 0 || len(vertices)%stride != 0 {
+		return mesh
+	}
+	vertNum := len(vertices) / stride
+	mesh.Indices = make([]uint32, 0, vertNum)
+	indexOf := map[string]uint32{}
+	key := strings.Builder{}
+	for i := 0; i < vertNum; i++ {
+		vert := vertices[i*stride : (i+1)*stride]
+		key.Reset()
+		for _, v := range vert {
+			if v == 0 {
+				v = 0 // -0 and 0 are the same vertex
+			}
+			bits := math.Float32bits(v)
+			key.WriteByte(byte(bits))
+			key.WriteByte(byte(bits >> 8))
+			key.WriteByte(byte(bits >> 16))
+			key.WriteByte(byte(bits >> 24))
+		}
+		idx, ok := indexOf[key.String()]
+		if !ok {
+			idx = uint32(len(mesh.Vertices) / stride)
+			indexOf[key.String()] = idx
+			mesh.Vertices = append(mesh.Vertices, vert...)
+		}
+		mesh.Indices = append(mesh.Indices, idx)
+	}
+	return mesh
+}
+
+// Expand turns the indexed mesh back into a vertex array whose every three
+// vertices form a triangle, which could be used by the objects that don't
+// use indices.
+func (m *IndexedMesh) Expand() []float32 {
+	vertices := make([]float32, 0, len(m.Indices)*m.Stride)
+	for _, idx := range m.Indices {
+		i := int(idx) * m.Stride
+		vertices = append(vertices, m.Vertices[i:i+m.Stride]...)
+	}
+	return vertices
+}
+
+// windingEdge is a use of an edge by a triangle. forward tells whether the
+// triangle goes along the edge from the smaller vertex to the larger one.
+type windingEdge struct {
+	tri     int
+	forward bool
+}
+
+// windingEdges returns the uses of the edges of the triangles, keyed by the
+// welded positions of the two ends with the smaller one first. The degenerate
+// edges are skipped.
+func windingEdges(vertices []float32, stride int) map[[2]uint32][]windingEdge {
+	idOf := map[[3]uint32]uint32{}
+	vertNum := len(vertices) / stride
+	ids := make([]uint32, vertNum)
+	for i := 0; i < vertNum; i++ {
+		v := vertices[i*stride:]
+		key := positionKey(mgl32.Vec3{v[0], v[1], v[2]})
+		id, ok := idOf[key]
+		if !ok {
+			id = uint32(len(idOf))
+			idOf[key] = id
+		}
+		ids[i] = id
+	}
+	edges := map[[2]uint32][]windingEdge{}
+	for t := 0; t < vertNum/3; t++ {
+		for c := 0; c < 3; c++ {
+			a, b := ids[t*3+c], ids[t*3+(c+1)%3]
+			if a == b {
+				continue
+			}
+			if a < b {
+				edges[[2]uint32{a, b}] = append(edges[[2]uint32{a, b}], windingEdge{tri: t, forward: true})
+			} else {
+				edges[[2]uint32{b, a}] = append(edges[[2]uint32{b, a}], windingEdge{tri: t, forward: false})
+			}
+		}
+	}
+	return edges
+}
+
+// CheckWinding returns the number of the edges where two triangles sharing
+// the edge go along it in the same direction, i.e. their winding orders are
+// inconsistent, so one of their normals computed by AddNormal() is flipped.
+// It's 0 if the winding of the mesh is consistent. The vertices are the same
+// as WeldVertices(), and the triangles are connected by the positions
+// (the first 3 values) of their vertices.
+func CheckWinding(vertices []float32, stride int) int {
+	if stride < 3 || len(vertices)%(stride*3) != 0 {
+		return 0
+	}
+	inconsistent := 0
+	for _, uses := range windingEdges(vertices, stride) {
+		for i := 0; i < len(uses); i++ {
+			for j := i + 1; j < len(uses); j++ {
+				if uses[i].forward == uses[j].forward {
+					inconsistent++
+				}
+			}
+		}
+	}
+	return inconsistent
+}
+
+// FixWinding flips the triangles (by swapping their second and third
+// vertices) in place, so the connected triangles have the same winding order,
+// and returns the number of the flipped triangles. The closed meshes are
+// turned counter-clockwise seen from outside, so AddNormal() makes their
+// normals face outward. For the open meshes (e.g. planes), the winding of
+// the majority of the triangles is kept.
+//
+// The vertices are the same as WeldVertices(). The normals in the vertices
+// are not changed, so the winding should be fixed before computing the
+// normals.
+func FixWinding(vertices []float32, stride int) int {
+	if stride < 3 || len(vertices)%(stride*3) != 0 {
+		return 0
+	}
+	triNum := len(vertices) / stride / 3
+	edges := windingEdges(vertices, stride)
+	// the edges of the triangles, to find their neighbors
+	triEdges := make([][][2]uint32, triNum)
+	for key, uses := range edges {
+		for _, use := range uses {
+			triEdges[use.tri] = append(triEdges[use.tri], key)
+		}
+	}
+
+	flip := make([]bool, triNum)
+	visited := make([]bool, triNum)
+	for seed := 0; seed < triNum; seed++ {
+		if visited[seed] {
+			continue
+		}
+		// flood the connected triangles from the seed
+		component := []int{seed}
+		visited[seed] = true
+		closed := true
+		for i := 0; i < len(component); i++ {
+			t := component[i]
+			for _, key := range triEdges[t] {
+				uses := edges[key]
+				if len(uses) != 2 {
+					closed = false
+				}
+				var forward bool
+				for _, use := range uses {
+					if use.tri == t {
+						forward = use.forward
+					}
+				}
+				for _, use := range uses {
+					if visited[use.tri] {
+						continue
+					}
+					// the neighbor should go along the edge in the other direction
+					flip[use.tri] = use.forward == (forward != flip[t])
+					visited[use.tri] = true
+					component = append(component, use.tri)
+				}
+			}
+		}
+
+		flipped := 0
+		for _, t := range component {
+			if flip[t] {
+				flipped++
+			}
+		}
+		invert := flipped*2 > len(component)
+		if closed {
+			// the signed volume is positive if the triangles face outward
+			volume := float32(0)
+			for _, t := range component {
+				v1, v2, v3 := windingTriangle(vertices, stride, t)
+				if flip[t] {
+					v2, v3 = v3, v2
+				}
+				volume += v1.Dot(v2.Cross(v3))
+			}
+			invert = volume < 0
+		}
+		if invert {
+			for _, t := range component {
+				flip[t] = !flip[t]
+			}
+		}
+	}
+
+	flipped := 0
+	tmp := make([]float32, stride)
+	for t := 0; t < triNum; t++ {
+		if !flip[t] {
+			continue
+		}
+		v2 := vertices[(t*3+1)*stride : (t*3+2)*stride]
+		v3 := vertices[(t*3+2)*stride : (t*3+3)*stride]
+		copy(tmp, v2)
+		copy(v2, v3)
+		copy(v3, tmp)
+		flipped++
+	}
+	return flipped
+}
+
+// windingTriangle returns the positions of the triangle t.
+func windingTriangle(vertices []float32, stride int, t int) (mgl32.Vec3, mgl32.Vec3, mgl32.Vec3) {
+	pos := func(i int) mgl32.Vec3 {
+		v := vertices[(t*3+i)*stride:]
+		return mgl32.Vec3{v[0], v[1], v[2]}
+	}
+	return pos(0), pos(1), pos(2)
+}
@@ -0,0 +1,205 @@
+package sgl
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestWeldVertices(t *testing.T) {
+	negZero := float32(math.Copysign(0, -1))
+	tests := []struct {
+		name     string
+		vertices []float32
+		stride   int
+		unique   int
+		indices  []uint32
+	}{
+		{
+			name: "shared edge",
+			vertices: []float32{
+				0, 0, 0, 1, 0, 0, 0, 1, 0,
+				1, 0, 0, 1, 1, 0, 0, 1, 0,
+			},
+			stride:  3,
+			unique:  4,
+			indices: []uint32{0, 1, 2, 1, 3, 2},
+		},
+		{
+			name: "negative zero",
+			vertices: []float32{
+				0, 0, 0, 1, 0, 0, 0, 1, 0,
+				negZero, 0, negZero, 0, 1, 0, 1, 0, 0,
+			},
+			stride:  3,
+			unique:  3,
+			indices: []uint32{0, 1, 2, 0, 2, 1},
+		},
+		{
+			name: "same position with different normals",
+			vertices: []float32{
+				0, 0, 0, 0, 0, 1,
+				1, 0, 0, 0, 0, 1,
+				0, 1, 0, 0, 0, 1,
+				0, 0, 0, 0, 1, 0,
+				1, 0, 0, 0, 1, 0,
+				0, 1, 0, 0, 1, 0,
+			},
+			stride:  6,
+			unique:  6,
+			indices: []uint32{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name:     "invalid stride",
+			vertices: []float32{0, 0, 0, 1},
+			stride:   3,
+			unique:   0,
+			indices:  nil,
+		},
+		{
+			name:     "zero stride",
+			vertices: []float32{0, 0, 0},
+			stride:   0,
+			unique:   0,
+			indices:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mesh := WeldVertices(tt.vertices, tt.stride)
+			if mesh.Stride != tt.stride {
+				t.Errorf("got stride %v, want %v", mesh.Stride, tt.stride)
+			}
+			if tt.stride > 0 && len(mesh.Vertices)/tt.stride != tt.unique {
+				t.Errorf("got %v unique vertices, want %v", len(mesh.Vertices)/tt.stride, tt.unique)
+			}
+			if fmt.Sprint(mesh.Indices) != fmt.Sprint(tt.indices) {
+				t.Errorf("got indices %v, want %v", mesh.Indices, tt.indices)
+			}
+		})
+	}
+}
+
+func TestWeldVerticesExpand(t *testing.T) {
+	cube := *NewCube(2)
+	mesh := WeldVertices(cube, 3)
+	if len(mesh.Vertices) != 8*3 || len(mesh.Indices) != 36 {
+		t.Fatalf("got %v vertices and %v indices, want 8 and 36",
+			len(mesh.Vertices)/3, len(mesh.Indices))
+	}
+	if got := mesh.Expand(); !equalFloats(got, cube) {
+		t.Errorf("got %v, want %v", got, cube)
+	}
+
+	withNormal := AddNormal(cube)
+	mesh = WeldVertices(withNormal, 6)
+	// 4 corners for each of the 6 faces
+	if len(mesh.Vertices) != 24*6 {
+		t.Errorf("got %v vertices, want 24", len(mesh.Vertices)/6)
+	}
+	if got := mesh.Expand(); !equalFloats(got, withNormal) {
+		t.Errorf("got %v, want %v", got, withNormal)
+	}
+}
+
+// flipTriangles returns a copy of the vertices whose triangles tris are
+// flipped by swapping their second and third vertices.
+func flipTriangles(vertices []float32, stride int, tris ...int) []float32 {
+	flipped := append([]float32{}, vertices...)
+	for _, t := range tris {
+		v2 := flipped[(t*3+1)*stride : (t*3+2)*stride]
+		v3 := flipped[(t*3+2)*stride : (t*3+3)*stride]
+		for i := range v2 {
+			v2[i], v3[i] = v3[i], v2[i]
+		}
+	}
+	return flipped
+}
+
+func TestWinding(t *testing.T) {
+	cube := *NewCube(2)
+	allTriangles := make([]int, len(cube)/9)
+	for i := range allTriangles {
+		allTriangles[i] = i
+	}
+	plane := []float32{
+		0, 0, 0, 1, 0, 0, 1, 1, 0,
+		0, 0, 0, 1, 1, 0, 0, 1, 0,
+	}
+	withNormal := AddNormal(cube)
+	tests := []struct {
+		name         string
+		vertices     []float32
+		stride       int
+		inconsistent int
+		flipped      int
+		want         []float32
+	}{
+		{
+			name:     "consistent cube",
+			vertices: cube,
+			stride:   3,
+			want:     cube,
+		},
+		{
+			name:         "cube with a flipped triangle",
+			vertices:     flipTriangles(cube, 3, 5),
+			stride:       3,
+			inconsistent: 3,
+			flipped:      1,
+			want:         cube,
+		},
+		{
+			name:     "inside-out cube",
+			vertices: flipTriangles(cube, 3, allTriangles...),
+			stride:   3,
+			flipped:  len(allTriangles),
+			want:     cube,
+		},
+		{
+			name:         "cube with normals",
+			vertices:     flipTriangles(withNormal, 6, 0),
+			stride:       6,
+			inconsistent: 3,
+			flipped:      1,
+			want:         withNormal,
+		},
+		{
+			name:         "plane keeps the winding of the majority",
+			vertices:     flipTriangles(plane, 3, 1),
+			stride:       3,
+			inconsistent: 1,
+			flipped:      1,
+			want:         plane,
+		},
+		{
+			name:     "clockwise plane",
+			vertices: flipTriangles(plane, 3, 0, 1),
+			stride:   3,
+			want:     flipTriangles(plane, 3, 0, 1),
+		},
+		{
+			name:     "invalid stride",
+			vertices: []float32{0, 0, 0, 1},
+			stride:   3,
+			want:     []float32{0, 0, 0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckWinding(tt.vertices, tt.stride); got != tt.inconsistent {
+				t.Errorf("CheckWinding() = %v, want %v", got, tt.inconsistent)
+			}
+			vertices := append([]float32{}, tt.vertices...)
+			if got := FixWinding(vertices, tt.stride); got != tt.flipped {
+				t.Errorf("FixWinding() = %v, want %v", got, tt.flipped)
+			}
+			if !equalFloats(vertices, tt.want) {
+				t.Errorf("got %v, want %v", vertices, tt.want)
+			}
+			if got := CheckWinding(vertices, tt.stride); got != 0 {
+				t.Errorf("CheckWinding() = %v after FixWinding(), want 0", got)
+			}
+		})
+	}
+}
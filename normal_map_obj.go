@@ -0,0 +1,194 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// NormalMapObjVar is the program variable struct for NormalMapObj.
+// The object is lit by all the lights of Lights, or by Ls if Lights is nil.
+// It receives the shadows of Shadow if it's not nil, and reflects Env by
+// Mt.Reflectivity if it's not nil.
+type NormalMapObjVar struct {
+	// Texture is the color of the object. The object is white if it's nil.
+	Texture *Texture
+
+	// NormalMap is the normal map in tangent space, whose red, green and
+	// blue channels are the tangent, the bitangent and the normal mapped
+	// from [-1, 1] to [0, 1]. The bitangent points to where v increases,
+	// which is the OpenGL convention when the normal map is loaded with
+	// TextureOptions.FlipY. It shouldn't be an sRGB texture. The normals
+	// of the vertices are used if it's nil.
+	NormalMap *Texture
+
+	Vp     *Viewpoint
+	Ls     *LightSrc
+	Lights *LightSet
+	Mt     *Material
+	Shadow *ShadowMap
+	Env    *CubeMap
+}
+
+// NormalMapObj is the Object struct that will render a textured object whose
+// surface details come from a normal map. It's lit like TexObj, but the
+// normals are sampled from the normal map in the tangent space of the
+// vertices (see AddTangent()).
+type NormalMapObj struct {
+	progVar NormalMapObjVar
+
+	BaseObj
+}
+
+// NewNormalMapObj returns a NormalMapObj instance with its program.
+func NewNormalMapObj() Object {
+	obj := &NormalMapObj{}
+	obj.SetProgram(makeBuiltinProgram(getNormalMapObjVS(), getNormalMapObjFS()))
+	obj.OwnProgram = true
+
+	return obj
+}
+
+func (obj *NormalMapObj) GetProgVar() interface{} {
+	return obj.progVar
+}
+
+func (obj *NormalMapObj) SetProgVar(progVar interface{}) {
+	pv, ok := progVar.(NormalMapObjVar)
+	if !ok {
+		panic("progVar is not a NormalMapObjVar")
+	}
+	obj.SetVar(pv)
+}
+
+// Var gets the program variables of the object.
+func (obj *NormalMapObj) Var() NormalMapObjVar {
+	return obj.progVar
+}
+
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *NormalMapObj) SetVar(progVar NormalMapObjVar) {
+	obj.progVar = progVar
+
+	obj.BindProgVar(obj.progVar)
+	gl.UseProgram(obj.Program)
+	gl.Uniform1i(gl.GetUniformLocation(obj.Program, gl.Str("tex\x00")), 0)
+	gl.Uniform1i(gl.GetUniformLocation(obj.Program, gl.Str("normalMap\x00")), 1)
+	for _, name := range []string{"hasTexture", "hasNormalMap", "envEnabled"} {
+		obj.Uniform[name] = gl.GetUniformLocation(obj.Program, gl.Str(name+"\x00"))
+	}
+	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
+}
+
+// SetVertices sets the vertices of x, y, z, u, v (e.g. NewUniTexCube()),
+// and computes the normals and the tangents.
+func (obj *NormalMapObj) SetVertices(vertices *[]float32) {
+	newVertices := AddNormalWithTexCoord(*vertices)
+	obj.SetVerticesWithNormalAndTexCoord(&newVertices)
+}
+
+// SetVerticesWithNormalAndTexCoord sets the vertices of
+// x, y, z, nx, ny, nz, u, v, and computes the tangents.
+// (e.g. ObjMesh.VerticesWithNormalAndTexCoord())
+func (obj *NormalMapObj) SetVerticesWithNormalAndTexCoord(vertices *[]float32) {
+	newVertices := AddTangent(*vertices)
+	obj.SetVerticesWithTangent(&newVertices)
+}
+
+// SetVerticesWithTangent sets the vertices of
+// x, y, z, nx, ny, nz, u, v, tx, ty, tz, tw. (e.g. AddTangent())
+func (obj *NormalMapObj) SetVerticesWithTangent(vertices *[]float32) {
+	obj.Layout = PosNormalTexTangentLayout
+	obj.BaseObj.SetVertices(vertices)
+}
+
+func (obj *NormalMapObj) Render() {
+	gl.UseProgram(obj.Program)
+	obj.Binder.Upload(&obj.progVar)
+	obj.bindLighting(obj.progVar.Lights, obj.progVar.Ls, obj.progVar.Shadow, obj.progVar.Env)
+	if obj.progVar.Texture != nil {
+		obj.progVar.Texture.Bind(0)
+		gl.Uniform1i(obj.Uniform["hasTexture"], 1)
+	} else {
+		gl.Uniform1i(obj.Uniform["hasTexture"], 0)
+	}
+	if obj.progVar.NormalMap != nil {
+		obj.progVar.NormalMap.Bind(1)
+		gl.Uniform1i(obj.Uniform["hasNormalMap"], 1)
+	} else {
+		gl.Uniform1i(obj.Uniform["hasNormalMap"], 0)
+	}
+	obj.drawModel(obj.Uniform["model"])
+}
+
+// getNormalMapObjVS returns the vertex shader of NormalMapObj
+func getNormalMapObjVS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+
+		layout(location = 0) in vec3 aPos;
+		layout(location = 1) in vec3 aNormal;
+		layout(location = 2) in vec2 aTexCoord;
+		layout(location = 3) in vec4 aTangent;
+
+		out vec3 FragPos;
+		out vec2 TexCoord;
+		out mat3 TBN;
+
+		#include "sgl/transform.glsl"
+
+		void main() {
+			FragPos = vec3(model * vec4(aPos, 1.0));
+			TexCoord = aTexCoord;
+
+			mat3 normalMatrix = mat3(transpose(inverse(model)));
+			vec3 N = normalize(normalMatrix * aNormal);
+			vec3 T = normalize(mat3(model) * aTangent.xyz);
+			// keep the tangent perpendicular to the normal after the transform
+			T = normalize(T - dot(T, N) * N);
+			vec3 B = cross(N, T) * aTangent.w;
+			TBN = mat3(T, B, N);
+
+			gl_Position = projection * camera * vec4(FragPos, 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
+
+// getNormalMapObjFS returns the fragment shader of NormalMapObj
+func getNormalMapObjFS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		out vec4 FragColor;
+
+		in vec3 FragPos;
+		in vec2 TexCoord;
+		in mat3 TBN;
+
+		uniform sampler2D tex;
+		uniform sampler2D normalMap;
+		uniform bool hasTexture;
+		uniform bool hasNormalMap;
+
+		#include "sgl/phong_lights.glsl"
+
+		void main() {
+			vec4 color = vec4(1.0);
+			if (hasTexture) {
+				color = texture(tex, TexCoord);
+			}
+			vec3 normal = TBN[2];
+			if (hasNormalMap) {
+				vec3 n = texture(normalMap, TexCoord).rgb * 2.0 - 1.0;
+				normal = TBN * n;
+			}
+			FragColor = vec4(phongLights(normal, FragPos, color.rgb), color.a);
+		}
+		%v`,
+		"\x00",
+	)
+}
@@ -44,6 +44,11 @@ type Object interface {
 	// object's states in the main loop (i.e. uniform variables)
 	// should have already been prepared when calling SetProgVar().
 	Render()
+
+	// Delete frees the GPU resources of the object, such as VAO, VBO,
+	// EBO, textures and the program owned by the object.
+	// The object can't be rendered after being deleted.
+	Delete()
 }
 
 // BaseObjVar is the program variable struct for BaseObj.
@@ -62,14 +67,36 @@ type BaseObj struct {
 	// Program is the shader program of the object.
 	Program uint32
 
+	// OwnProgram tells whether the program is owned by the object, and
+	// then the program will be deleted by Delete(). The objects created by
+	// NewXXX() own their programs. Set it false before calling Delete() if
+	// the program is shared with other objects.
+	OwnProgram bool
+
 	// Vao stands for "Vertex Array Object", and it contains
 	// one or more "Vertex Buffer Object" which is a memory
 	// buffer that contains the data of vertices
 	Vao uint32
 
+	// Vbo is the "Vertex Buffer Object" that contains Vertices.
+	Vbo uint32
+
 	// Vertices are points that form the shape of the object.
 	Vertices *[]float32
 
+	// Layout describes the attributes of the vertices.
+	// PosLayout is used if it's nil.
+	Layout VertexLayout
+
+	// Ebo stands for "Element Buffer Object", and it contains
+	// the indices of the vertices that form the triangles.
+	// It's 0 if the object is not indexed.
+	Ebo uint32
+
+	// Indices are the indices of the vertices that form the triangles.
+	// It's nil if the object is not indexed.
+	Indices *[]uint32
+
 	// Model keeps translation/rotation info of the object.
 	Model mgl32.Mat4
 
@@ -77,11 +104,19 @@ type BaseObj struct {
 	// shader program and itself.
 	Uniform map[string]int32
 
+	// Binder uploads the fields of ProgVar to the uniform variables
+	// in Render(). It's set by BindProgVar().
+	Binder *UniformBinder
+
 	// ProgVar is the customizes struct that contains all the uniform
 	// variables that will be used in the shader program of a certain object.
 	// Developer should implement their own ProgVar and put it here to shadow
 	// this BaseObjVar type of ProgVar
 	ProgVar BaseObjVar
+
+	// lsLights is the LightSet of a single LightSrc, used by bindLighting
+	// when the object is not given a LightSet.
+	lsLights LightSet
 }
 
 // NewBaseObj return a BaseObj instance with its program.
@@ -101,7 +136,8 @@ func NewBaseObj() Object {
 	// considering the file paths related to the executed file,
 	// put shader codes into a string also is a way.
 	obj := &BaseObj{}
-	obj.SetProgram(MakeProgram(getBaseObjVS(), getBaseObjFS()))
+	obj.SetProgram(makeBuiltinProgram(getBaseObjVS(), getBaseObjFS()))
+	obj.OwnProgram = true
 
 	return obj
 }
@@ -110,8 +146,10 @@ func (obj *BaseObj) GetProgram() uint32 {
 	return obj.Program
 }
 
+// SetProgram sets an existing program, which is not owned by the object.
 func (obj *BaseObj) SetProgram(program uint32) {
 	obj.Program = program
+	obj.OwnProgram = false
 }
 
 func (obj *BaseObj) GetProgVar() interface{} {
@@ -119,25 +157,66 @@ func (obj *BaseObj) GetProgVar() interface{} {
 }
 
 func (obj *BaseObj) SetProgVar(progVar interface{}) {
-	if pv, ok := progVar.(BaseObjVar); ok {
-		obj.ProgVar = pv
-	} else {
+	pv, ok := progVar.(BaseObjVar)
+	if !ok {
 		panic("progVar is not a BaseObjVar")
 	}
+	obj.SetVar(pv)
+}
 
-	obj.Uniform = map[string]int32{}
+// Var gets the program variables of the object.
+func (obj *BaseObj) Var() BaseObjVar {
+	return obj.ProgVar
+}
 
-	obj.Uniform["project"] = gl.GetUniformLocation(obj.Program, gl.Str("projection\x00"))
-	obj.Uniform["camera"] = gl.GetUniformLocation(obj.Program, gl.Str("camera\x00"))
-	obj.Uniform["model"] = gl.GetUniformLocation(obj.Program, gl.Str("model\x00"))
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *BaseObj) SetVar(progVar BaseObjVar) {
+	obj.ProgVar = progVar
+
+	obj.BindProgVar(obj.ProgVar)
 	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
 }
 
+// BindProgVar makes the Binder of the program variable struct, and sets
+// Uniform to the locations of its uniform variables and the model.
+// It's usually called by SetProgVar(), see UniformBinder for the struct tags.
+func (obj *BaseObj) BindProgVar(progVar interface{}) {
+	binder, err := NewUniformBinder(obj.Program, progVar)
+	if err != nil {
+		panic(err)
+	}
+	obj.Binder = binder
+	obj.Uniform = binder.Locations()
+	obj.Uniform["model"] = gl.GetUniformLocation(obj.Program, gl.Str("model\x00"))
+}
+
 func (obj *BaseObj) GetVertices() *[]float32 {
 	return obj.Vertices
 }
 
 func (obj *BaseObj) SetVertices(vertices *[]float32) {
+	if obj.Layout == nil {
+		obj.Layout = PosLayout
+	}
+	// the EBO belongs to the old vertices, call SetIndices() again if needed
+	obj.deleteEbo()
+
+	// reuse the VAO and VBO if the size of the vertices doesn't change
+	if obj.Vao != 0 && obj.Vertices != nil && len(*obj.Vertices) == len(*vertices) {
+		obj.Vertices = vertices
+		gl.BindVertexArray(obj.Vao)
+		gl.BindBuffer(gl.ARRAY_BUFFER, obj.Vbo)
+		gl.BufferSubData(
+			gl.ARRAY_BUFFER,
+			0,
+			len(*vertices)*4, // 4 is the size of float32
+			gl.Ptr(*vertices),
+		)
+		obj.Layout.Enable(obj.Program)
+		return
+	}
+	obj.deleteVao()
 	obj.Vertices = vertices
 
 	var vao uint32
@@ -156,19 +235,80 @@ func (obj *BaseObj) SetVertices(vertices *[]float32) {
 		gl.STATIC_DRAW,
 	)
 
-	vertAttrib := uint32(0) // 0 is the index of variable "vert" defined in vShader
+	obj.Layout.Enable(obj.Program)
 
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointerWithOffset(
-		vertAttrib,
-		3,
-		gl.FLOAT,
-		false,
-		3*4, // 4 is the size of float32, and there're 5 floats per vertex in the vertex array.
-		0,
+	obj.Vao = vao
+	obj.Vbo = vbo
+}
+
+// SetIndices makes the object indexed. It should be called after
+// SetVertices(), and the indices are the indices of the vertices (e.g. the
+// output of WeldVertices()). The indexed objects are drawn by DrawElements.
+func (obj *BaseObj) SetIndices(indices *[]uint32) {
+	gl.BindVertexArray(obj.Vao)
+
+	// reuse the EBO if the number of the indices doesn't change
+	if obj.Ebo != 0 && obj.Indices != nil && len(*obj.Indices) == len(*indices) {
+		obj.Indices = indices
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, obj.Ebo)
+		gl.BufferSubData(
+			gl.ELEMENT_ARRAY_BUFFER,
+			0,
+			len(*indices)*4, // 4 is the size of uint32
+			gl.Ptr(*indices),
+		)
+		return
+	}
+	obj.deleteEbo()
+	obj.Indices = indices
+
+	var ebo uint32
+	gl.GenBuffers(1, &ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
+	gl.BufferData(
+		gl.ELEMENT_ARRAY_BUFFER,
+		len(*indices)*4, // 4 is the size of uint32
+		gl.Ptr(*indices),
+		gl.STATIC_DRAW,
 	)
 
-	obj.Vao = vao
+	obj.Ebo = ebo
+}
+
+// Delete frees the VAO, VBO and EBO of the object, and the program
+// if the object owns it.
+func (obj *BaseObj) Delete() {
+	obj.deleteEbo()
+	obj.deleteVao()
+	if obj.OwnProgram && obj.Program != 0 {
+		gl.DeleteProgram(obj.Program)
+	}
+	obj.Program = 0
+	obj.OwnProgram = false
+}
+
+// deleteVao deletes the VAO and the VBO.
+func (obj *BaseObj) deleteVao() {
+	if obj.Vbo != 0 {
+		gl.DeleteBuffers(1, &obj.Vbo)
+	}
+	if obj.Vao != 0 {
+		gl.DeleteVertexArrays(1, &obj.Vao)
+	}
+	obj.Vbo = 0
+	obj.Vao = 0
+	obj.Vertices = nil
+}
+
+// deleteEbo deletes the EBO. The VAO is bound first so that the EBO
+// is detached from it.
+func (obj *BaseObj) deleteEbo() {
+	if obj.Ebo != 0 {
+		gl.BindVertexArray(obj.Vao)
+		gl.DeleteBuffers(1, &obj.Ebo)
+	}
+	obj.Ebo = 0
+	obj.Indices = nil
 }
 
 func (obj *BaseObj) GetModel() mgl32.Mat4 {
@@ -179,14 +319,52 @@ func (obj *BaseObj) SetModel(model mgl32.Mat4) {
 	obj.Model = model
 }
 
+// DrawShadow draws the triangles of the object into a ShadowMap.
+func (obj *BaseObj) DrawShadow(modelLocation int32) {
+	obj.drawModel(modelLocation)
+}
+
 func (obj *BaseObj) Render() {
 	gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 	gl.UseProgram(obj.Program)
-	gl.UniformMatrix4fv(obj.Uniform["project"], 1, false, &(obj.ProgVar.Vp.Projection[0]))
-	gl.UniformMatrix4fv(obj.Uniform["camera"], 1, false, &(obj.ProgVar.Vp.Camera[0]))
-	gl.UniformMatrix4fv(obj.Uniform["model"], 1, false, &obj.Model[0])
+	obj.Binder.Upload(&obj.ProgVar)
+	obj.drawModel(obj.Uniform["model"])
+}
+
+// drawModel sets the model matrix to modelLocation and draws the triangles.
+func (obj *BaseObj) drawModel(modelLocation int32) {
+	gl.UniformMatrix4fv(modelLocation, 1, false, &obj.Model[0])
 	gl.BindVertexArray(obj.Vao)
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(*obj.Vertices)/3)) // 6: X,Y,Z
+	obj.Draw(gl.TRIANGLES)
+}
+
+// bindLighting binds the lights, the shadow map and the environment map of
+// a lit object. The object is lit by ls if lights is nil, and by nothing if
+// both are nil. The shadows are disabled if shadow is nil, and env is
+// ignored if the program has no "envEnabled" uniform variable.
+func (obj *BaseObj) bindLighting(lights *LightSet, ls *LightSrc, shadow *ShadowMap, env *CubeMap) {
+	if lights == nil {
+		updateLightSrcSet(&obj.lsLights, ls)
+	}
+	if shadow != nil {
+		shadow.BindTexture()
+	} else {
+		gl.Uniform1i(obj.Uniform["shadowEnabled"], 0)
+	}
+	if location, ok := obj.Uniform["envEnabled"]; ok {
+		bindEnvMap(env, location)
+	}
+}
+
+// Draw draws the vertices of the object with DrawElements if the object is
+// indexed, otherwise with DrawArrays. The VAO of the object should have
+// been bound.
+func (obj *BaseObj) Draw(mode uint32) {
+	if obj.Indices != nil {
+		gl.DrawElementsWithOffset(mode, int32(len(*obj.Indices)), gl.UNSIGNED_INT, 0)
+		return
+	}
+	gl.DrawArrays(mode, 0, int32(len(*obj.Vertices)*4)/obj.Layout.Stride()) // 4 is the size of float32
 }
 
 // getBaseObjVS returns the vertex shader of BaseObj
@@ -194,12 +372,10 @@ func getBaseObjVS() string {
 	return fmt.Sprintf(
 		`
 		#version 330
-		uniform mat4 projection;
-		uniform mat4 camera;
-		uniform mat4 model;
-		layout (location = 0) in vec3 vert;
+		#include "sgl/transform.glsl"
+		layout (location = 0) in vec3 aPos;
 		void main() {
-			gl_Position = projection * camera * model * vec4(vert, 1);
+			gl_Position = projection * camera * model * vec4(aPos, 1);
 		}
 		%v`,
 		"\x00",
@@ -226,116 +402,92 @@ func getBaseObjFS() string {
 }
 
 // SimpleObjVar is the program variable struct for SimpleObj.
+// The object is lit by all the lights of Lights, or by Ls if Lights is nil.
+// It's not lit by any light if both are nil. It receives the shadows of
+// Shadow if it's not nil, and reflects Env by Mt.Reflectivity if it's not nil.
 type SimpleObjVar struct {
-	Red   float32
-	Green float32
-	Blue  float32
-	Vp    *Viewpoint
-	Ls    *LightSrc
-	Mt    *Material
+	Red    float32 `sgl:"red"`
+	Green  float32 `sgl:"green"`
+	Blue   float32 `sgl:"blue"`
+	Vp     *Viewpoint
+	Ls     *LightSrc
+	Lights *LightSet
+	Mt     *Material
+	Shadow *ShadowMap
+	Env    *CubeMap
 }
 
 // SimpleObj is the Object struct that will render a mono color object which
 // has certain material properties. The mono color object will reflect the light
-// from a single light source.
+// from a single light source or a set of directional, point and spot lights.
 type SimpleObj struct {
 	progVar SimpleObjVar
+
+	// NormalOptions are the options of the normals computed by SetVertices(),
+	// e.g. NormalOptions{Smooth: true} for curved surfaces. The normals are
+	// flat and follow the counter-clockwise winding order by default.
+	NormalOptions NormalOptions
+
 	BaseObj
 }
 
 // NewSimpleObj returns a SimpleObj instance with its program.
 func NewSimpleObj() Object {
 	obj := &SimpleObj{}
-	obj.SetProgram(MakeProgram(getSimpleObjVS(), getSimpleObjFS()))
+	obj.SetProgram(makeBuiltinProgram(getSimpleObjVS(), getSimpleObjFS()))
+	obj.OwnProgram = true
 
 	return obj
 }
 
+func (obj *SimpleObj) GetProgVar() interface{} {
+	return obj.progVar
+}
+
 func (obj *SimpleObj) SetProgVar(progVar interface{}) {
-	if pv, ok := progVar.(SimpleObjVar); ok {
-		obj.progVar = pv
-	} else {
+	pv, ok := progVar.(SimpleObjVar)
+	if !ok {
 		panic("progVar is not a SimpleObjVar")
 	}
+	obj.SetVar(pv)
+}
 
-	obj.Uniform = map[string]int32{}
+// Var gets the program variables of the object.
+func (obj *SimpleObj) Var() SimpleObjVar {
+	return obj.progVar
+}
+
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *SimpleObj) SetVar(progVar SimpleObjVar) {
+	obj.progVar = progVar
+
+	obj.BindProgVar(obj.progVar)
+	obj.Uniform["envEnabled"] = gl.GetUniformLocation(obj.Program, gl.Str("envEnabled\x00"))
 
-	obj.Uniform["project"] = gl.GetUniformLocation(obj.Program, gl.Str("projection\x00"))
-	obj.Uniform["camera"] = gl.GetUniformLocation(obj.Program, gl.Str("camera\x00"))
-	obj.Uniform["model"] = gl.GetUniformLocation(obj.Program, gl.Str("model\x00"))
-	obj.Uniform["lightPos"] = gl.GetUniformLocation(obj.Program, gl.Str("lightPos\x00"))
-	obj.Uniform["lightColor"] = gl.GetUniformLocation(obj.Program, gl.Str("lightColor\x00"))
-	obj.Uniform["lightIntensity"] = gl.GetUniformLocation(obj.Program, gl.Str("lightIntensity\x00"))
-	obj.Uniform["viewPos"] = gl.GetUniformLocation(obj.Program, gl.Str("viewPos\x00"))
-	obj.Uniform["red"] = gl.GetUniformLocation(obj.Program, gl.Str("red\x00"))
-	obj.Uniform["green"] = gl.GetUniformLocation(obj.Program, gl.Str("green\x00"))
-	obj.Uniform["blue"] = gl.GetUniformLocation(obj.Program, gl.Str("blue\x00"))
-	obj.Uniform["materialAmbient"] = gl.GetUniformLocation(obj.Program, gl.Str("materialAmbient\x00"))
-	obj.Uniform["materialDiffuse"] = gl.GetUniformLocation(obj.Program, gl.Str("materialDiffuse\x00"))
-	obj.Uniform["materialSpecular"] = gl.GetUniformLocation(obj.Program, gl.Str("materialSpecular\x00"))
-	obj.Uniform["materialShininess"] = gl.GetUniformLocation(obj.Program, gl.Str("materialShininess\x00"))
 	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
 }
 
+// SetVertices sets the vertices of x, y, z, and computes the normals by
+// NormalOptions.
 func (obj *SimpleObj) SetVertices(vertices *[]float32) {
-	newVertices := AddNormal(*vertices)
-	obj.Vertices = &newVertices
-
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(
-		gl.ARRAY_BUFFER,
-		len(*obj.Vertices)*4, // 4 is the size of float32
-		gl.Ptr(*obj.Vertices),
-		gl.STATIC_DRAW,
-	)
+	newVertices := AddNormalWithOptions(*vertices, obj.NormalOptions)
+	obj.SetVerticesWithNormal(&newVertices)
+}
 
-	vertAttrib := uint32(0) // 0 is the index of variable "aPos" defined in vShader
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointerWithOffset(
-		vertAttrib,
-		3,
-		gl.FLOAT,
-		false,
-		6*4, // 4 is the size of float32, and there are 6 floats per vertex in the vertex array.
-		0,
-	)
-	normal := uint32(1) // 1 is the index of variable "aNormal" defined in vShader
-	gl.EnableVertexAttribArray(normal)
-	gl.VertexAttribPointerWithOffset(
-		normal,
-		3,
-		gl.FLOAT,
-		false,
-		6*4, // 4 is the size of float32, and there are 6 floats per vertex in the vertex array.
-		3*4, // use offset 3*4 because we use only last 3 floats of each vertex here.
-	)
-	obj.Vao = vao
+// SetVerticesWithNormal sets the vertices of the object without computing
+// the normals. The vertex array should contain 6 float32 values per vertex:
+// x, y, z, nx, ny, nz. (e.g. the output of AddNormal() or ReadStlWithNormal())
+func (obj *SimpleObj) SetVerticesWithNormal(vertices *[]float32) {
+	obj.Layout = PosNormalLayout
+	obj.BaseObj.SetVertices(vertices)
 }
 
 func (obj *SimpleObj) Render() {
 	gl.UseProgram(obj.Program)
-	gl.UniformMatrix4fv(obj.Uniform["project"], 1, false, &(obj.progVar.Vp.Projection[0]))
-	gl.UniformMatrix4fv(obj.Uniform["camera"], 1, false, &(obj.progVar.Vp.Camera[0]))
-	gl.UniformMatrix4fv(obj.Uniform["model"], 1, false, &obj.Model[0])
-	gl.Uniform3fv(obj.Uniform["lightPos"], 1, &(obj.progVar.Ls.Pos[0]))
-	gl.Uniform3fv(obj.Uniform["lightColor"], 1, &(obj.progVar.Ls.Color[0]))
-	gl.Uniform3fv(obj.Uniform["viewPos"], 1, &(obj.progVar.Vp.Eye[0]))
-	gl.Uniform1f(obj.Uniform["lightIntensity"], obj.progVar.Ls.Intensity)
-	gl.Uniform1f(obj.Uniform["red"], obj.progVar.Red)
-	gl.Uniform1f(obj.Uniform["green"], obj.progVar.Green)
-	gl.Uniform1f(obj.Uniform["blue"], obj.progVar.Blue)
-	gl.Uniform3fv(obj.Uniform["materialAmbient"], 1, &(obj.progVar.Mt.Ambient[0]))
-	gl.Uniform3fv(obj.Uniform["materialDiffuse"], 1, &(obj.progVar.Mt.Diffuse[0]))
-	gl.Uniform3fv(obj.Uniform["materialSpecular"], 1, &(obj.progVar.Mt.Specular[0]))
-	gl.Uniform1f(obj.Uniform["materialShininess"], obj.progVar.Mt.Shininess)
-	gl.BindVertexArray(obj.Vao)
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(*obj.Vertices)/6)) // 6: X,Y,Z,NX,NY,NZ
+	obj.Binder.Upload(&obj.progVar)
+	obj.bindLighting(obj.progVar.Lights, obj.progVar.Ls, obj.progVar.Shadow, obj.progVar.Env)
+	obj.drawModel(obj.Uniform["model"])
 }
 
 // getSimpleObjVS returns the vertex shader of SimpleObj
@@ -350,9 +502,7 @@ func getSimpleObjVS() string {
 		out vec3 FragPos;
 		out vec3 Normal;
 
-		uniform mat4 projection;
-		uniform mat4 camera;
-		uniform mat4 model;
+		#include "sgl/transform.glsl"
 
 		void main() {
     		FragPos = vec3(model * vec4(aPos, 1.0));
@@ -380,41 +530,146 @@ func getSimpleObjFS() string {
 		in vec3 Normal;
 		in vec3 FragPos;
 
-		uniform vec3 viewPos;
-
 		uniform float red;
 		uniform float green;
 		uniform float blue;
 
-		uniform vec3 lightPos;
-		uniform vec3 lightColor;
-		uniform float lightIntensity;
+		#include "sgl/phong_lights.glsl"
+
+		void main() {
+			vec3 objectColor = vec3(red, green, blue);
+			FragColor = vec4(phongLights(Normal, FragPos, objectColor), 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
+
+// ColorObjVar is the program variable struct for ColorObj.
+type ColorObjVar struct {
+	Vp *Viewpoint
+	Ls *LightSrc
+	Mt *Material
+}
+
+// ColorObj is the Object struct that will render an object whose color is
+// defined per vertex, like the colored meshes and point clouds of PLY files.
+// The vertex array should contain 9 float32 values per vertex:
+// x, y, z, nx, ny, nz, r, g, b. The vertices with zero normals (e.g. the
+// points of a point cloud without normals) are not lit by the light source.
+type ColorObj struct {
+	progVar ColorObjVar
+
+	// Points makes the object render the vertices as points instead of
+	// triangles, which is used for point clouds.
+	Points bool
+
+	BaseObj
+}
+
+// NewColorObj returns a ColorObj instance with its program.
+func NewColorObj() Object {
+	obj := &ColorObj{}
+	obj.SetProgram(makeBuiltinProgram(getColorObjVS(), getColorObjFS()))
+	obj.OwnProgram = true
+
+	return obj
+}
+
+func (obj *ColorObj) GetProgVar() interface{} {
+	return obj.progVar
+}
+
+func (obj *ColorObj) SetProgVar(progVar interface{}) {
+	pv, ok := progVar.(ColorObjVar)
+	if !ok {
+		panic("progVar is not a ColorObjVar")
+	}
+	obj.SetVar(pv)
+}
+
+// Var gets the program variables of the object.
+func (obj *ColorObj) Var() ColorObjVar {
+	return obj.progVar
+}
+
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *ColorObj) SetVar(progVar ColorObjVar) {
+	obj.progVar = progVar
+
+	obj.BindProgVar(obj.progVar)
+
+	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
+}
+
+func (obj *ColorObj) SetVertices(vertices *[]float32) {
+	obj.Layout = PosNormalColorLayout
+	obj.BaseObj.SetVertices(vertices)
+}
+
+func (obj *ColorObj) Render() {
+	gl.UseProgram(obj.Program)
+	obj.Binder.Upload(&obj.progVar)
+	gl.UniformMatrix4fv(obj.Uniform["model"], 1, false, &obj.Model[0])
+	gl.BindVertexArray(obj.Vao)
+	mode := uint32(gl.TRIANGLES)
+	if obj.Points {
+		mode = gl.POINTS
+	}
+	obj.Draw(mode)
+}
+
+// getColorObjVS returns the vertex shader of ColorObj
+func getColorObjVS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+
+		layout(location = 0) in vec3 aPos;
+		layout(location = 1) in vec3 aNormal;
+		layout(location = 2) in vec3 aColor;
+
+		out vec3 FragPos;
+		out vec3 Normal;
+		out vec3 Color;
 
-		uniform vec3 materialAmbient;
-		uniform vec3 materialDiffuse;
-		uniform vec3 materialSpecular;
-		uniform float materialShininess;
+		#include "sgl/transform.glsl"
 
 		void main() {
-    		vec3 objectColor = vec3(red, green, blue);
+			FragPos = vec3(model * vec4(aPos, 1.0));
+			Normal = mat3(transpose(inverse(model))) * aNormal;
+			Color = aColor;
+
+			gl_Position = projection * camera * vec4(FragPos, 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
 
-			// ambient
-    		vec3 ambient = lightColor * materialAmbient;
+// getColorObjFS returns the fragment shader of ColorObj
+// It's the same lighting as SimpleObj, but the object color comes from
+// the vertices.
+func getColorObjFS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		out vec4 FragColor;
 
-			// diffuse 
-    		vec3 norm = normalize(Normal);
-    		vec3 lightDir = normalize(lightPos - FragPos);
-    		float diff = max(dot(norm, lightDir), 0.0);
-    		vec3 diffuse = (lightIntensity * lightColor) * (diff * materialDiffuse);
+		in vec3 Normal;
+		in vec3 FragPos;
+		in vec3 Color;
 
-			// specular
-    		vec3 viewDir = normalize(viewPos - FragPos);
-    		vec3 reflectDir = reflect(-lightDir, norm);
-    		float spec = pow(max(dot(viewDir, reflectDir), 0.0), materialShininess);
-    		vec3 specular = lightColor * (spec * materialSpecular);
+		#include "sgl/phong.glsl"
 
-    		vec3 result = (ambient + diffuse + specular) * objectColor;
-    		FragColor = vec4(result, 1.0);
+		void main() {
+			// vertices without normals are not lit
+			if (length(Normal) == 0.0) {
+				FragColor = vec4(Color, 1.0);
+				return;
+			}
+			FragColor = vec4(phong(Normal, FragPos, Color), 1.0);
 		}
 		%v`,
 		"\x00",
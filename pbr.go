@@ -0,0 +1,215 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// PbrObjVar is the program variable struct for PbrObj.
+// The object is lit by all the lights of Lights, or by Ls if Lights is nil.
+// It receives the shadows of Shadow if it's not nil.
+type PbrObjVar struct {
+	Vp     *Viewpoint
+	Ls     *LightSrc
+	Lights *LightSet
+	Mt     *PbrMaterial
+	Shadow *ShadowMap
+}
+
+// PbrObj is the Object struct that will render an object with a physically
+// based material (see PbrMaterial), which is lit by the Cook-Torrance BRDF.
+type PbrObj struct {
+	progVar PbrObjVar
+
+	// NormalOptions are the options of the normals computed by SetVertices().
+	// The normals are flat by default.
+	NormalOptions NormalOptions
+
+	BaseObj
+}
+
+// pbrMaps are the samplers of the textures of PbrMaterial and the flags
+// telling whether they're set. The texture units are their indices.
+var pbrMaps = []struct {
+	sampler string
+	flag    string
+}{
+	{"albedoMap", "hasAlbedoMap"},
+	{"metallicMap", "hasMetallicMap"},
+	{"roughnessMap", "hasRoughnessMap"},
+	{"aoMap", "hasAoMap"},
+	{"emissiveMap", "hasEmissiveMap"},
+}
+
+// NewPbrObj returns a PbrObj instance with its program.
+func NewPbrObj() Object {
+	obj := &PbrObj{}
+	obj.SetProgram(makeBuiltinProgram(getPbrObjVS(), getPbrObjFS()))
+	obj.OwnProgram = true
+
+	return obj
+}
+
+func (obj *PbrObj) GetProgVar() interface{} {
+	return obj.progVar
+}
+
+func (obj *PbrObj) SetProgVar(progVar interface{}) {
+	pv, ok := progVar.(PbrObjVar)
+	if !ok {
+		panic("progVar is not a PbrObjVar")
+	}
+	obj.SetVar(pv)
+}
+
+// Var gets the program variables of the object.
+func (obj *PbrObj) Var() PbrObjVar {
+	return obj.progVar
+}
+
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *PbrObj) SetVar(progVar PbrObjVar) {
+	obj.progVar = progVar
+
+	obj.BindProgVar(obj.progVar)
+	gl.UseProgram(obj.Program)
+	for i, m := range pbrMaps {
+		gl.Uniform1i(gl.GetUniformLocation(obj.Program, gl.Str(m.sampler+"\x00")), int32(i))
+		obj.Uniform[m.flag] = gl.GetUniformLocation(obj.Program, gl.Str(m.flag+"\x00"))
+	}
+	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
+}
+
+// SetVertices sets the vertices of x, y, z, and computes the normals by
+// NormalOptions. The objects without texture coordinates can't use the
+// textures of PbrMaterial.
+func (obj *PbrObj) SetVertices(vertices *[]float32) {
+	newVertices := AddNormalWithOptions(*vertices, obj.NormalOptions)
+	obj.SetVerticesWithNormal(&newVertices)
+}
+
+// SetVerticesWithNormal sets the vertices of x, y, z, nx, ny, nz.
+func (obj *PbrObj) SetVerticesWithNormal(vertices *[]float32) {
+	obj.Layout = PosNormalLayout
+	obj.BaseObj.SetVertices(vertices)
+}
+
+// SetVerticesWithNormalAndTexCoord sets the vertices of
+// x, y, z, nx, ny, nz, u, v. (e.g. ObjMesh.VerticesWithNormalAndTexCoord())
+func (obj *PbrObj) SetVerticesWithNormalAndTexCoord(vertices *[]float32) {
+	obj.Layout = PosNormalTexLayout
+	obj.BaseObj.SetVertices(vertices)
+}
+
+func (obj *PbrObj) Render() {
+	gl.UseProgram(obj.Program)
+	obj.Binder.Upload(&obj.progVar)
+	obj.bindLighting(obj.progVar.Lights, obj.progVar.Ls, obj.progVar.Shadow, nil)
+	obj.bindMaps()
+	obj.drawModel(obj.Uniform["model"])
+}
+
+// bindMaps binds the textures of the material to their texture units.
+func (obj *PbrObj) bindMaps() {
+	var textures [5]*Texture
+	if mt := obj.progVar.Mt; mt != nil {
+		textures = [5]*Texture{mt.AlbedoMap, mt.MetallicMap, mt.RoughnessMap, mt.AOMap, mt.EmissiveMap}
+	}
+	for i, m := range pbrMaps {
+		if textures[i] == nil {
+			gl.Uniform1i(obj.Uniform[m.flag], 0)
+			continue
+		}
+		gl.Uniform1i(obj.Uniform[m.flag], 1)
+		textures[i].Bind(int32(i))
+	}
+}
+
+// getPbrObjVS returns the vertex shader of PbrObj
+func getPbrObjVS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+
+		layout(location = 0) in vec3 aPos;
+		layout(location = 1) in vec3 aNormal;
+		layout(location = 2) in vec2 aTexCoord;
+
+		out vec3 FragPos;
+		out vec3 Normal;
+		out vec2 TexCoord;
+
+		#include "sgl/transform.glsl"
+
+		void main() {
+			FragPos = vec3(model * vec4(aPos, 1.0));
+			Normal = mat3(transpose(inverse(model))) * aNormal;
+			TexCoord = aTexCoord;
+
+			gl_Position = projection * camera * vec4(FragPos, 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
+
+// getPbrObjFS returns the fragment shader of PbrObj
+func getPbrObjFS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		out vec4 FragColor;
+
+		in vec3 Normal;
+		in vec3 FragPos;
+		in vec2 TexCoord;
+
+		uniform vec3 albedo;
+		uniform float metallic;
+		uniform float roughness;
+		uniform float ao;
+		uniform vec3 emissive;
+
+		uniform sampler2D albedoMap;
+		uniform sampler2D metallicMap;
+		uniform sampler2D roughnessMap;
+		uniform sampler2D aoMap;
+		uniform sampler2D emissiveMap;
+		uniform bool hasAlbedoMap;
+		uniform bool hasMetallicMap;
+		uniform bool hasRoughnessMap;
+		uniform bool hasAoMap;
+		uniform bool hasEmissiveMap;
+
+		#include "sgl/pbr.glsl"
+
+		void main() {
+			vec3 a = albedo;
+			float m = metallic;
+			float r = roughness;
+			float o = ao;
+			vec3 e = emissive;
+			if (hasAlbedoMap) {
+				a *= texture(albedoMap, TexCoord).rgb;
+			}
+			if (hasMetallicMap) {
+				m *= texture(metallicMap, TexCoord).r;
+			}
+			if (hasRoughnessMap) {
+				r *= texture(roughnessMap, TexCoord).r;
+			}
+			if (hasAoMap) {
+				o *= texture(aoMap, TexCoord).r;
+			}
+			if (hasEmissiveMap) {
+				e *= texture(emissiveMap, TexCoord).rgb;
+			}
+			vec3 color = pbrLights(Normal, FragPos, a, m, r, o, e);
+			FragColor = vec4(linearToSrgb(color), 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
@@ -0,0 +1,409 @@
+package sgl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PlyData is the data read from a PLY (Stanford polygon) file.
+type PlyData struct {
+	// Vertices is the interleaved vertex array. Each vertex contains
+	// x, y, z, followed by nx, ny, nz if HasNormal is true, followed by
+	// r, g, b if HasColor is true. The colors are in the range of [0, 1].
+	// Faces are triangulated and expanded, so every three vertices form a
+	// triangle, unless IsPointCloud is true.
+	Vertices []float32
+
+	// HasNormal tells whether the vertices contain normals.
+	HasNormal bool
+
+	// HasColor tells whether the vertices contain colors.
+	HasColor bool
+
+	// IsPointCloud is true if the file doesn't have any face, and then
+	// Vertices contains the points in the order of the file.
+	IsPointCloud bool
+}
+
+// Stride returns the number of float32 values per vertex.
+func (d *PlyData) Stride() int {
+	stride := 3
+	if d.HasNormal {
+		stride += 3
+	}
+	if d.HasColor {
+		stride += 3
+	}
+	return stride
+}
+
+// VerticesWithNormalAndColor returns the vertex array that contains 9 float32
+// values per vertex: x, y, z, nx, ny, nz, r, g, b, which could be used by
+// ColorObj. If the data doesn't have normals, the normals of triangles are
+// computed from the counter-clockwise winding order, and the normals of points
+// are zero. If the data doesn't have colors, defaultColor is used.
+func (d *PlyData) VerticesWithNormalAndColor(defaultColor mgl32.Vec3) []float32 {
+	stride := d.Stride()
+	vertNum := len(d.Vertices) / stride
+	vertices := make([]float32, 0, vertNum*9)
+	normal := mgl32.Vec3{}
+	for i := 0; i < vertNum; i++ {
+		vert := d.Vertices[i*stride : (i+1)*stride]
+		if d.HasNormal {
+			normal = mgl32.Vec3{vert[3], vert[4], vert[5]}
+		} else if !d.IsPointCloud && i%3 == 0 && i+2 < vertNum {
+			// the first vertex of a triangle
+			next := d.Vertices[(i+1)*stride:]
+			last := d.Vertices[(i+2)*stride:]
+			normal = faceNormal(
+				mgl32.Vec3{vert[0], vert[1], vert[2]},
+				mgl32.Vec3{next[0], next[1], next[2]},
+				mgl32.Vec3{last[0], last[1], last[2]},
+			)
+		}
+		color := defaultColor
+		if d.HasColor {
+			color = mgl32.Vec3{vert[stride-3], vert[stride-2], vert[stride-1]}
+		}
+		vertices = append(vertices, vert[0], vert[1], vert[2])
+		vertices = append(vertices, normal[0], normal[1], normal[2])
+		vertices = append(vertices, color[0], color[1], color[2])
+	}
+	return vertices
+}
+
+// PlySyntaxError is returned when a PLY input is malformed.
+type PlySyntaxError struct {
+	Msg string
+}
+
+func (e *PlySyntaxError) Error() string {
+	return "ply syntax error: " + e.Msg
+}
+
+// ReadPlyFile reads a PLY file.
+func ReadPlyFile(file string) (*PlyData, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := ReadPly(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", file, err)
+	}
+	return data, nil
+}
+
+// ReadPly reads PLY data from r. The ASCII, the binary little-endian and
+// the binary big-endian formats are supported.
+// The "vertex" element provides the positions (x, y, z), the normals
+// (nx, ny, nz) and the colors (red, green, blue), and the "face" element
+// provides the polygons in its "vertex_indices" (or "vertex_index") list,
+// which are triangulated as triangle fans. All the other elements and
+// properties are skipped.
+func ReadPly(r io.Reader) (*PlyData, error) {
+	br := bufio.NewReader(r)
+	format, elements, err := readPlyHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var vr plyValueReader
+	switch format {
+	case "ascii":
+		scanner := bufio.NewScanner(br)
+		scanner.Split(bufio.ScanWords)
+		vr = &plyAsciiReader{scanner: scanner}
+	case "binary_little_endian":
+		vr = &plyBinaryReader{r: br, order: binary.LittleEndian}
+	case "binary_big_endian":
+		vr = &plyBinaryReader{r: br, order: binary.BigEndian}
+	default:
+		return nil, &PlySyntaxError{Msg: "unknown format " + format}
+	}
+
+	// rows of the vertex element, and the vertex indices of the faces
+	vertices := [][]float64{}
+	var vertexElem *plyElement
+	faces := [][]int{}
+	for e := range elements {
+		elem := &elements[e]
+		if elem.name == "vertex" {
+			vertexElem = elem
+		}
+		faceProp := -1
+		if elem.name == "face" {
+			for i, prop := range elem.props {
+				if prop.isList && (prop.name == "vertex_indices" || prop.name == "vertex_index") {
+					faceProp = i
+				}
+			}
+		}
+		for i := 0; i < elem.count; i++ {
+			row := make([]float64, len(elem.props))
+			for p, prop := range elem.props {
+				if !prop.isList {
+					if row[p], err = vr.read(prop.typ); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				n, err := vr.read(prop.countTyp)
+				if err != nil {
+					return nil, err
+				}
+				if n < 0 || n != math.Trunc(n) || n > math.MaxInt32 {
+					return nil, &PlySyntaxError{Msg: fmt.Sprintf("invalid list length %v", n)}
+				}
+				// the length could be corrupt, so the list grows with the
+				// values that are actually read instead of being allocated
+				list := []int{}
+				for j := 0; j < int(n); j++ {
+					v, err := vr.read(prop.typ)
+					if err != nil {
+						return nil, err
+					}
+					list = append(list, int(v))
+				}
+				if p == faceProp {
+					faces = append(faces, list)
+				}
+			}
+			if elem == vertexElem {
+				vertices = append(vertices, row)
+			}
+		}
+	}
+	if vertexElem == nil {
+		return nil, &PlySyntaxError{Msg: "no vertex element"}
+	}
+	return newPlyData(vertexElem, vertices, faces)
+}
+
+// newPlyData picks the positions, normals and colors from the vertex rows
+// and expands the faces into triangles.
+func newPlyData(elem *plyElement, rows [][]float64, faces [][]int) (*PlyData, error) {
+	find := func(names ...string) []int {
+		indices := []int{}
+		for _, name := range names {
+			for i, prop := range elem.props {
+				if prop.name == name && !prop.isList {
+					indices = append(indices, i)
+					break
+				}
+			}
+		}
+		if len(indices) != len(names) {
+			return nil
+		}
+		return indices
+	}
+	pos := find("x", "y", "z")
+	if pos == nil {
+		return nil, &PlySyntaxError{Msg: "vertex element doesn't have x, y and z"}
+	}
+	normal := find("nx", "ny", "nz")
+	color := find("red", "green", "blue")
+	if color == nil {
+		color = find("r", "g", "b")
+	}
+	// integer colors are mapped to [0, 1]
+	colorScale := 1.0
+	if color != nil {
+		switch elem.props[color[0]].typ {
+		case "uchar", "uint8":
+			colorScale = 255
+		case "ushort", "uint16":
+			colorScale = 65535
+		}
+	}
+
+	data := &PlyData{
+		HasNormal:    normal != nil,
+		HasColor:     color != nil,
+		IsPointCloud: len(faces) == 0,
+	}
+	appendVertex := func(row []float64) {
+		for _, i := range pos {
+			data.Vertices = append(data.Vertices, float32(row[i]))
+		}
+		for _, i := range normal {
+			data.Vertices = append(data.Vertices, float32(row[i]))
+		}
+		for _, i := range color {
+			data.Vertices = append(data.Vertices, float32(row[i]/colorScale))
+		}
+	}
+
+	if data.IsPointCloud {
+		for _, row := range rows {
+			appendVertex(row)
+		}
+		return data, nil
+	}
+	for _, face := range faces {
+		for _, idx := range face {
+			if idx < 0 || idx >= len(rows) {
+				return nil, &PlySyntaxError{Msg: fmt.Sprintf("vertex index %v is out of range", idx)}
+			}
+		}
+		// triangle fan: (0, 1, 2), (0, 2, 3), ...
+		for i := 1; i+1 < len(face); i++ {
+			appendVertex(rows[face[0]])
+			appendVertex(rows[face[i]])
+			appendVertex(rows[face[i+1]])
+		}
+	}
+	return data, nil
+}
+
+type plyProperty struct {
+	name     string
+	typ      string
+	isList   bool
+	countTyp string
+}
+
+type plyElement struct {
+	name  string
+	count int
+	props []plyProperty
+}
+
+// plyTypeSizes maps the PLY types to their sizes in bytes.
+var plyTypeSizes = map[string]int{
+	"char": 1, "uchar": 1, "int8": 1, "uint8": 1,
+	"short": 2, "ushort": 2, "int16": 2, "uint16": 2,
+	"int": 4, "uint": 4, "int32": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+// readPlyHeader reads the header until "end_header", and returns
+// the format and the elements.
+func readPlyHeader(br *bufio.Reader) (string, []plyElement, error) {
+	format := ""
+	elements := []plyElement{}
+	first := true
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return "", nil, &PlySyntaxError{Msg: "header is truncated"}
+		}
+		fields := strings.Fields(line)
+		if first {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return "", nil, &PlySyntaxError{Msg: "missing magic number \"ply\""}
+			}
+			first = false
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return "", nil, &PlySyntaxError{Msg: "invalid format line"}
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return "", nil, &PlySyntaxError{Msg: "invalid element line"}
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return "", nil, &PlySyntaxError{Msg: "invalid element count " + fields[2]}
+			}
+			elements = append(elements, plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return "", nil, &PlySyntaxError{Msg: "property without element"}
+			}
+			prop := plyProperty{}
+			if len(fields) == 5 && fields[1] == "list" {
+				prop = plyProperty{name: fields[4], typ: fields[3], isList: true, countTyp: fields[2]}
+			} else if len(fields) == 3 {
+				prop = plyProperty{name: fields[2], typ: fields[1]}
+			} else {
+				return "", nil, &PlySyntaxError{Msg: "invalid property line"}
+			}
+			if _, ok := plyTypeSizes[prop.typ]; !ok {
+				return "", nil, &PlySyntaxError{Msg: "unknown property type " + prop.typ}
+			}
+			if _, ok := plyTypeSizes[prop.countTyp]; prop.isList && !ok {
+				return "", nil, &PlySyntaxError{Msg: "unknown property type " + prop.countTyp}
+			}
+			last := &elements[len(elements)-1]
+			last.props = append(last.props, prop)
+		case "end_header":
+			if format == "" {
+				return "", nil, &PlySyntaxError{Msg: "missing format"}
+			}
+			return format, elements, nil
+		}
+	}
+}
+
+// plyValueReader reads the values of the body one by one.
+type plyValueReader interface {
+	read(typ string) (float64, error)
+}
+
+type plyAsciiReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *plyAsciiReader) read(typ string) (float64, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return 0, err
+		}
+		return 0, &PlySyntaxError{Msg: "body is truncated"}
+	}
+	v, err := strconv.ParseFloat(r.scanner.Text(), 64)
+	if err != nil {
+		return 0, &PlySyntaxError{Msg: "invalid value " + r.scanner.Text()}
+	}
+	return v, nil
+}
+
+type plyBinaryReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (r *plyBinaryReader) read(typ string) (float64, error) {
+	b := r.buf[:plyTypeSizes[typ]]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return 0, &PlySyntaxError{Msg: "body is truncated"}
+	}
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(b)), nil
+	}
+}
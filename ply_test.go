@@ -0,0 +1,150 @@
+package sgl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const plyAsciiQuad = `ply
+format ascii 1.0
+comment a colored quad
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0 255 0 0
+1 0 0 0 255 0
+1 1 0 0 0 255
+0 1 0 255 255 255
+4 0 1 2 3
+`
+
+// plyBinaryTriangle returns a binary PLY triangle whose list length is n.
+func plyBinaryTriangle(order binary.ByteOrder, format string, n uint32) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("ply\nformat " + format + " 1.0\n" +
+		"element vertex 3\nproperty float x\nproperty float y\nproperty float z\n" +
+		"property float nx\nproperty float ny\nproperty float nz\n" +
+		"element face 1\nproperty list uint int vertex_indices\nend_header\n")
+	binary.Write(buf, order, []float32{
+		0, 0, 0, 0, 0, 1,
+		1, 0, 0, 0, 0, 1,
+		0, 1, 0, 0, 0, 1,
+	})
+	binary.Write(buf, order, n)
+	binary.Write(buf, order, []int32{0, 1, 2})
+	return buf.Bytes()
+}
+
+func TestReadPly(t *testing.T) {
+	data, err := ReadPly(strings.NewReader(plyAsciiQuad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.HasNormal || !data.HasColor || data.IsPointCloud || data.Stride() != 6 {
+		t.Fatalf("got %+v", data)
+	}
+	// triangle fan (0, 1, 2), (0, 2, 3) with colors in [0, 1]
+	want := []float32{
+		0, 0, 0, 1, 0, 0,
+		1, 0, 0, 0, 1, 0,
+		1, 1, 0, 0, 0, 1,
+		0, 0, 0, 1, 0, 0,
+		1, 1, 0, 0, 0, 1,
+		0, 1, 0, 1, 1, 1,
+	}
+	if !equalFloats(data.Vertices, want) {
+		t.Errorf("got %v, want %v", data.Vertices, want)
+	}
+
+	withNormal := data.VerticesWithNormalAndColor(mgl32.Vec3{})
+	if len(withNormal) != 6*9 || withNormal[5] != 1 || withNormal[6] != 1 {
+		t.Errorf("got VerticesWithNormalAndColor() %v", withNormal)
+	}
+}
+
+func TestReadPlyBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		order  binary.ByteOrder
+		format string
+	}{
+		{"little endian", binary.LittleEndian, "binary_little_endian"},
+		{"big endian", binary.BigEndian, "binary_big_endian"},
+	}
+	want := []float32{
+		0, 0, 0, 0, 0, 1,
+		1, 0, 0, 0, 0, 1,
+		0, 1, 0, 0, 0, 1,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ReadPly(bytes.NewReader(plyBinaryTriangle(tt.order, tt.format, 3)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !data.HasNormal || data.HasColor || !equalFloats(data.Vertices, want) {
+				t.Errorf("got %+v", data)
+			}
+		})
+	}
+}
+
+func TestReadPlyPointCloud(t *testing.T) {
+	input := "ply\nformat ascii 1.0\nelement vertex 2\n" +
+		"property double x\nproperty double y\nproperty double z\nend_header\n" +
+		"1 2 3\n4 5 6\n"
+	data, err := ReadPly(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.IsPointCloud || !equalFloats(data.Vertices, []float32{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("got %+v", data)
+	}
+}
+
+func TestReadPlyErrors(t *testing.T) {
+	header := "ply\nformat ascii 1.0\nelement vertex 3\n" +
+		"property float x\nproperty float y\nproperty float z\n" +
+		"element face 1\nproperty list uchar int vertex_indices\nend_header\n" +
+		"0 0 0\n1 0 0\n0 1 0\n"
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"huge binary list length", plyBinaryTriangle(binary.LittleEndian, "binary_little_endian", 0xffffffff)},
+		{"huge ascii list length", []byte(header + "1e300 0 1 2\n")},
+		{"negative list length", []byte(header + "-1 0 1 2\n")},
+		{"fractional list length", []byte(header + "2.5 0 1 2\n")},
+		{"index out of range", []byte(header + "3 0 1 3\n")},
+		{"truncated body", []byte(header + "3 0 1\n")},
+		{"invalid value", []byte(header + "3 0 1 x\n")},
+		{"missing magic number", []byte("format ascii 1.0\nend_header\n")},
+		{"unknown format", []byte("ply\nformat text 1.0\nend_header\n")},
+		{"unknown type", []byte("ply\nformat ascii 1.0\nelement vertex 1\nproperty vec3 x\nend_header\n")},
+		{"negative element count", []byte("ply\nformat ascii 1.0\nelement vertex -1\nend_header\n")},
+		{"truncated header", []byte("ply\nformat ascii 1.0\n")},
+		{"no vertex element", []byte("ply\nformat ascii 1.0\nend_header\n")},
+		{"no positions", []byte("ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nend_header\n1\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ReadPly(bytes.NewReader(tt.input))
+			var syntaxErr *PlySyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %+v and error %v, want a PlySyntaxError", data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package sgl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// ShaderError is returned when a shader fails to compile or a program
+// fails to link. It keeps the GLSL info log and the source lines the log
+// refers to, so the error could be shown like
+// "tex_cube_obj.frag:12: 'foo' : undeclared identifier".
+type ShaderError struct {
+	// Stage is the shader stage, e.g. "vertex" or "fragment".
+	// It's "link" if the program fails to link.
+	Stage string
+
+	// File is the file name of the shader source.
+	// It's empty if the source is not read from a file.
+	File string
+
+	// Log is the raw info log of the shader or the program.
+	Log string
+
+	// Lines are the messages in the log that refer to source lines.
+	Lines []ShaderErrorLine
+}
+
+// ShaderErrorLine is a message of the info log that refers to a source line.
+type ShaderErrorLine struct {
+	// File is the file of the line. It's different from ShaderError.File
+	// if the line is in an included file.
+	File string
+
+	// Line is the line number, which starts from 1.
+	Line int
+
+	// Msg is the message of the line.
+	Msg string
+
+	// Source is the source code of the line.
+	Source string
+}
+
+func (e *ShaderError) Error() string {
+	name := e.File
+	if name == "" {
+		name = e.Stage + " shader"
+	}
+	if e.Stage == "link" {
+		return fmt.Sprintf("failed to link program: %v", strings.TrimSpace(e.Log))
+	}
+	if len(e.Lines) == 0 {
+		return fmt.Sprintf("%v: failed to compile: %v", name, strings.TrimSpace(e.Log))
+	}
+	msgs := []string{}
+	for _, l := range e.Lines {
+		lineName := name
+		if l.File != "" {
+			lineName = l.File
+		}
+		msgs = append(msgs, fmt.Sprintf(
+			"%v:%v: %v\n\t%v",
+			lineName, l.Line, l.Msg, strings.TrimSpace(l.Source),
+		))
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// shaderLogLine matches the lines of the info logs of common drivers:
+//
+//	ERROR: 0:12: 'foo' : undeclared identifier (Apple, ANGLE)
+//	0:12(5): error: `foo' undeclared (Mesa)
+//	0(12) : error C1008: undefined variable "foo" (NVIDIA)
+var shaderLogLine = regexp.MustCompile(
+	`^\s*(?:(?:ERROR|WARNING):\s*)?\d+(?::(\d+)(?:\(\d+\))?|\((\d+)\))\s*:\s*(.*)$`,
+)
+
+// newShaderError parses the info log and maps it to the source lines.
+// If lineMap is not nil, the lines are mapped to the original files.
+func newShaderError(
+	stage string, file string, source string, lineMap []ShaderLineRef, log string,
+) *ShaderError {
+	e := &ShaderError{Stage: stage, File: file, Log: log}
+	sourceLines := strings.Split(source, "\n")
+	for _, logLine := range strings.Split(log, "\n") {
+		m := shaderLogLine.FindStringSubmatch(strings.TrimRight(logLine, "\x00"))
+		if m == nil {
+			continue
+		}
+		num := m[1]
+		if num == "" {
+			num = m[2]
+		}
+		line, err := strconv.Atoi(num)
+		if err != nil {
+			continue
+		}
+		l := ShaderErrorLine{Line: line, Msg: m[3]}
+		if line >= 1 && line <= len(sourceLines) {
+			l.Source = sourceLines[line-1]
+		}
+		if line >= 1 && line <= len(lineMap) {
+			l.File = lineMap[line-1].File
+			l.Line = lineMap[line-1].Line
+		}
+		e.Lines = append(e.Lines, l)
+	}
+	return e
+}
+
+// shaderStageName returns the name of the shader stage of shaderType.
+func shaderStageName(shaderType uint32) string {
+	switch shaderType {
+	case gl.VERTEX_SHADER:
+		return "vertex"
+	case gl.TESS_CONTROL_SHADER:
+		return "tessellation control"
+	case gl.TESS_EVALUATION_SHADER:
+		return "tessellation evaluation"
+	case gl.GEOMETRY_SHADER:
+		return "geometry"
+	case gl.FRAGMENT_SHADER:
+		return "fragment"
+	case gl.COMPUTE_SHADER:
+		return "compute"
+	default:
+		return fmt.Sprintf("shader type 0x%x", shaderType)
+	}
+}
+
+// shaderMinVersions are the minimal OpenGL versions (major*10 + minor)
+// that support the shader stages.
+var shaderMinVersions = map[uint32]int32{
+	gl.VERTEX_SHADER:          20,
+	gl.FRAGMENT_SHADER:        20,
+	gl.GEOMETRY_SHADER:        32,
+	gl.TESS_CONTROL_SHADER:    40,
+	gl.TESS_EVALUATION_SHADER: 40,
+	gl.COMPUTE_SHADER:         43,
+}
+
+func compileShader(src ShaderSource) (uint32, error) {
+	shader := gl.CreateShader(src.Type)
+
+	csources, free := gl.Strs(terminateSource(src.Source))
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+
+		return 0, newShaderError(
+			shaderStageName(src.Type),
+			src.File,
+			strings.TrimRight(src.Source, "\x00"),
+			src.LineMap,
+			strings.TrimRight(log, "\x00"),
+		)
+	}
+
+	return shader, nil
+}
+
+// terminateSource makes sure the source ends with "\x00",
+// which is required by gl.Strs().
+func terminateSource(source string) string {
+	if strings.HasSuffix(source, "\x00") {
+		return source
+	}
+	return source + "\x00"
+}
+
+// NewProgram compiles the shaders and links them into a program.
+// It returns a *ShaderError if a shader fails to compile or the program
+// fails to link.
+func NewProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
+	return newProgram(vertexShaderSource, "", fragmentShaderSource, "")
+}
+
+// NewProgramFromFile reads the shaders from files, then compiles and links
+// them into a program. The file names are kept in the *ShaderError.
+// The shaders could include other files (see ShaderLoader).
+func NewProgramFromFile(vertPath string, fragPath string) (uint32, error) {
+	vs, err := ShaderFromFile(gl.VERTEX_SHADER, vertPath)
+	if err != nil {
+		return 0, err
+	}
+	fs, err := ShaderFromFile(gl.FRAGMENT_SHADER, fragPath)
+	if err != nil {
+		return 0, err
+	}
+	return NewProgramWithShaders(vs, fs)
+}
+
+func newProgram(vertSource, vertFile, fragSource, fragFile string) (uint32, error) {
+	return NewProgramWithShaders(
+		ShaderSource{Type: gl.VERTEX_SHADER, Source: vertSource, File: vertFile},
+		ShaderSource{Type: gl.FRAGMENT_SHADER, Source: fragSource, File: fragFile},
+	)
+}
+
+// ShaderSource is the source of a shader stage of a program.
+type ShaderSource struct {
+	// Type is the shader stage: gl.VERTEX_SHADER, gl.TESS_CONTROL_SHADER,
+	// gl.TESS_EVALUATION_SHADER, gl.GEOMETRY_SHADER, gl.FRAGMENT_SHADER
+	// or gl.COMPUTE_SHADER.
+	Type uint32
+
+	// Source is the GLSL source code.
+	Source string
+
+	// File is the file name of the source, which is used in the
+	// *ShaderError. It could be empty.
+	File string
+
+	// LineMap maps the lines of Source (LineMap[0] is line 1) to the lines
+	// of the original files. It's set by ShaderLoader, and it could be nil.
+	LineMap []ShaderLineRef
+
+	// Files are the files that Source is made from, i.e. File and the files
+	// it includes, without the built-in includes. They're set by
+	// ShaderLoader, e.g. for ProgramWatcher to watch the included files.
+	Files []string
+}
+
+// ShaderFromFile reads the source of a shader stage from a file,
+// and resolves its includes from the disk (see ShaderLoader).
+func ShaderFromFile(shaderType uint32, path string) (ShaderSource, error) {
+	return NewShaderLoader(nil).Load(shaderType, path)
+}
+
+// NewProgramWithShaders compiles the shaders of any set of stages and links
+// them into a program, e.g. a vertex shader, a geometry shader and a fragment
+// shader to draw wireframe overlays, or tessellation shaders to tessellate
+// NewPlane() on GPU (the objects should be drawn with gl.PATCHES then).
+// A compute shader can't be linked with other stages, and it requires
+// OpenGL 4.3, which is not available on every platform (e.g. macOS).
+func NewProgramWithShaders(sources ...ShaderSource) (uint32, error) {
+	if len(sources) == 0 {
+		return 0, fmt.Errorf("no shader to make a program")
+	}
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	stages := map[uint32]bool{}
+	for _, src := range sources {
+		minVersion, ok := shaderMinVersions[src.Type]
+		if !ok {
+			return 0, fmt.Errorf("unknown shader type 0x%x", src.Type)
+		}
+		// MAJOR_VERSION is not supported before OpenGL 3.0, skip the check then
+		if major > 0 && major*10+minor < minVersion {
+			return 0, fmt.Errorf(
+				"%v shader requires OpenGL %v.%v, but the context is OpenGL %v.%v",
+				shaderStageName(src.Type), minVersion/10, minVersion%10, major, minor,
+			)
+		}
+		if stages[src.Type] {
+			return 0, fmt.Errorf("more than one %v shader", shaderStageName(src.Type))
+		}
+		stages[src.Type] = true
+	}
+	if stages[gl.COMPUTE_SHADER] && len(stages) > 1 {
+		return 0, fmt.Errorf("compute shader can't be linked with other stages")
+	}
+
+	shaders := []uint32{}
+	defer func() {
+		for _, shader := range shaders {
+			gl.DeleteShader(shader)
+		}
+	}()
+	for _, src := range sources {
+		shader, err := compileShader(src)
+		if err != nil {
+			return 0, err
+		}
+		shaders = append(shaders, shader)
+	}
+
+	return linkProgram(shaders...)
+}
+
+// linkProgram links the compiled shaders into a program.
+func linkProgram(shaders ...uint32) (uint32, error) {
+	program := gl.CreateProgram()
+
+	for _, shader := range shaders {
+		gl.AttachShader(program, shader)
+	}
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		gl.DeleteProgram(program)
+
+		return 0, &ShaderError{Stage: "link", Log: strings.TrimRight(log, "\x00")}
+	}
+
+	for _, shader := range shaders {
+		gl.DetachShader(program, shader)
+	}
+	gl.UseProgram(program)
+
+	return program, nil
+}
+
+// MakeProgram is like NewProgram, but panics if the program can't be made.
+func MakeProgram(vertexShaderSource, fragmentShaderSource string) uint32 {
+	program, err := NewProgram(vertexShaderSource, fragmentShaderSource)
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// MakeProgramFromFile is like NewProgramFromFile, but panics if the program
+// can't be made.
+func MakeProgramFromFile(vertPath string, fragPath string) uint32 {
+	program, err := NewProgramFromFile(vertPath, fragPath)
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
@@ -0,0 +1,104 @@
+package sgl
+
+import (
+	"reflect"
+	"testing"
+)
+
+const shaderErrorSource = `#version 330 core
+out vec4 FragColor;
+void main()
+{
+    FragColor = foo;
+}`
+
+func TestNewShaderError(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []ShaderErrorLine
+	}{
+		{
+			name: "apple",
+			log:  "ERROR: 0:5: 'foo' : undeclared identifier\nERROR: 0:5: '' : compilation terminated\n",
+			want: []ShaderErrorLine{
+				{Line: 5, Msg: "'foo' : undeclared identifier", Source: "    FragColor = foo;"},
+				{Line: 5, Msg: "'' : compilation terminated", Source: "    FragColor = foo;"},
+			},
+		},
+		{
+			name: "mesa",
+			log:  "0:5(17): error: `foo' undeclared\n",
+			want: []ShaderErrorLine{
+				{Line: 5, Msg: "error: `foo' undeclared", Source: "    FragColor = foo;"},
+			},
+		},
+		{
+			name: "nvidia",
+			log:  "0(5) : error C1008: undefined variable \"foo\"\n\x00",
+			want: []ShaderErrorLine{
+				{Line: 5, Msg: "error C1008: undefined variable \"foo\"", Source: "    FragColor = foo;"},
+			},
+		},
+		{
+			name: "line out of range",
+			log:  "ERROR: 0:42: 'main' : function already has a body\n",
+			want: []ShaderErrorLine{
+				{Line: 42, Msg: "'main' : function already has a body", Source: ""},
+			},
+		},
+		{
+			name: "no line",
+			log:  "error: linking with uncompiled shader\n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		e := newShaderError("fragment", "", shaderErrorSource, nil, tt.log)
+		if !reflect.DeepEqual(e.Lines, tt.want) {
+			t.Errorf("%v: got lines %q, want %q", tt.name, e.Lines, tt.want)
+		}
+	}
+}
+
+func TestShaderErrorError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *ShaderError
+		want string
+	}{
+		{
+			name: "file",
+			err:  newShaderError("fragment", "obj.frag", shaderErrorSource, nil, "ERROR: 0:5: 'foo' : undeclared identifier"),
+			want: "obj.frag:5: 'foo' : undeclared identifier\n\tFragColor = foo;",
+		},
+		{
+			name: "no file",
+			err:  newShaderError("vertex", "", shaderErrorSource, nil, "ERROR: 0:5: 'foo' : undeclared identifier"),
+			want: "vertex shader:5: 'foo' : undeclared identifier\n\tFragColor = foo;",
+		},
+		{
+			name: "no lines",
+			err:  newShaderError("vertex", "obj.vert", shaderErrorSource, nil, "out of memory\n"),
+			want: "obj.vert: failed to compile: out of memory",
+		},
+		{
+			name: "link",
+			err:  &ShaderError{Stage: "link", Log: "error: fragment shader lacks main\n"},
+			want: "failed to link program: error: fragment shader lacks main",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTerminateSource(t *testing.T) {
+	for _, src := range []string{"void main() {}", "void main() {}\x00"} {
+		if got := terminateSource(src); got != "void main() {}\x00" {
+			t.Errorf("terminateSource(%q) = %q", src, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package sgl
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// ProgramWatcher keeps a program made from shader files up to date.
+// It polls the files and the files they include, and when they change, it
+// recompiles the program and sets the new program to the watched objects.
+// If the new shaders fail to compile, the previous program is kept.
+//
+// OpenGL calls must be made on the main thread, so Poll() should be called
+// in the main loop:
+//
+//	watcher, err := sgl.WatchProgramFromFile(vertPath, fragPath)
+//	...
+//	watcher.AddObject(cube)
+//	for !window.ShouldClose() {
+//		if _, err := watcher.Poll(); err != nil {
+//			log.Println(err)
+//		}
+//		...
+//	}
+type ProgramWatcher struct {
+	// VertPath is the path of the vertex shader.
+	VertPath string
+
+	// FragPath is the path of the fragment shader.
+	FragPath string
+
+	// StagePaths are the paths of the shaders of the other stages, keyed by
+	// their types, e.g. gl.GEOMETRY_SHADER. It could be nil.
+	StagePaths map[uint32]string
+
+	// Interval is the minimal interval between two checks of the files.
+	Interval time.Duration
+
+	program   uint32
+	objects   []Object
+	includes  []string
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+// WatchProgramFromFile makes a program from the shader files like
+// NewProgramFromFile(), and returns a ProgramWatcher that checks the files
+// every 500 milliseconds. The files included by the shaders are also
+// watched (see ShaderLoader).
+func WatchProgramFromFile(vertPath string, fragPath string) (*ProgramWatcher, error) {
+	return WatchProgramWithStages(vertPath, fragPath, nil)
+}
+
+// WatchProgramWithStages is like WatchProgramFromFile, but the program also
+// has the shaders of the other stages in stagePaths, e.g. a geometry shader
+// keyed by gl.GEOMETRY_SHADER (see NewProgramWithShaders()).
+func WatchProgramWithStages(
+	vertPath string,
+	fragPath string,
+	stagePaths map[uint32]string,
+) (*ProgramWatcher, error) {
+	w := &ProgramWatcher{
+		VertPath:   vertPath,
+		FragPath:   fragPath,
+		StagePaths: stagePaths,
+		Interval:   500 * time.Millisecond,
+	}
+	modTimes := w.getModTimes()
+	program, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	w.modTimes = w.addModTimes(modTimes)
+	w.program = program
+	w.lastCheck = time.Now()
+	return w, nil
+}
+
+// Program returns the current program.
+func (w *ProgramWatcher) Program() uint32 {
+	return w.program
+}
+
+// AddObject sets the current program to the object, and the object will get
+// the new program whenever the program is reloaded. The program is owned by
+// the watcher, so it won't be deleted by the Delete() of the object.
+func (w *ProgramWatcher) AddObject(obj Object) {
+	obj.SetProgram(w.program)
+	w.objects = append(w.objects, obj)
+}
+
+// Poll checks whether the shader files or the files they include have been
+// modified, and reloads the program if so. It returns true if the program is
+// reloaded. If the new shaders fail to compile, it returns the error and
+// keeps the previous program, and it won't try again until the files are
+// modified again.
+func (w *ProgramWatcher) Poll() (bool, error) {
+	now := time.Now()
+	if now.Sub(w.lastCheck) < w.Interval {
+		return false, nil
+	}
+	w.lastCheck = now
+
+	modTimes := w.getModTimes()
+	changed := false
+	for file, t := range modTimes {
+		if !t.Equal(w.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	program, err := w.load()
+	// the includes could be changed by the reload
+	w.modTimes = w.addModTimes(modTimes)
+	if err != nil {
+		return false, err
+	}
+	for _, obj := range w.objects {
+		obj.SetProgram(program)
+		// resolve the locations of the uniform variables in the new program
+		if progVar := obj.GetProgVar(); progVar != nil {
+			obj.SetProgVar(progVar)
+		}
+	}
+	gl.DeleteProgram(w.program)
+	w.program = program
+	return true, nil
+}
+
+// Delete deletes the current program.
+func (w *ProgramWatcher) Delete() {
+	gl.DeleteProgram(w.program)
+	w.program = 0
+	w.objects = nil
+}
+
+// load reads the shader files and makes the program. The files included by
+// the shaders are kept to be watched, unless the shaders can't be read.
+func (w *ProgramWatcher) load() (uint32, error) {
+	sources := []ShaderSource{}
+	includes := []string{}
+	for _, stage := range w.stages() {
+		src, err := ShaderFromFile(stage.shaderType, stage.path)
+		if err != nil {
+			return 0, err
+		}
+		sources = append(sources, src)
+		includes = append(includes, src.Files...)
+	}
+	w.includes = includes
+	return NewProgramWithShaders(sources...)
+}
+
+// watcherStage is a shader file of a stage of the program.
+type watcherStage struct {
+	shaderType uint32
+	path       string
+}
+
+// stages returns the shader files of the program, in the order of the
+// vertex shader, the other stages sorted by type, and the fragment shader.
+func (w *ProgramWatcher) stages() []watcherStage {
+	stages := []watcherStage{{gl.VERTEX_SHADER, w.VertPath}}
+	types := []int{}
+	for shaderType := range w.StagePaths {
+		types = append(types, int(shaderType))
+	}
+	sort.Ints(types)
+	for _, shaderType := range types {
+		stages = append(stages, watcherStage{uint32(shaderType), w.StagePaths[uint32(shaderType)]})
+	}
+	return append(stages, watcherStage{gl.FRAGMENT_SHADER, w.FragPath})
+}
+
+// getModTimes returns the modification times of the shader files and the
+// files they include. A file that can't be accessed has a zero time, e.g.
+// when an editor is replacing the file.
+func (w *ProgramWatcher) getModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, stage := range w.stages() {
+		modTimes[stage.path] = modTime(stage.path)
+	}
+	for _, file := range w.includes {
+		modTimes[file] = modTime(file)
+	}
+	return modTimes
+}
+
+// addModTimes adds the modification times of the included files that are
+// not in modTimes, which are found by the last load.
+func (w *ProgramWatcher) addModTimes(modTimes map[string]time.Time) map[string]time.Time {
+	for _, file := range w.includes {
+		if _, ok := modTimes[file]; !ok {
+			modTimes[file] = modTime(file)
+		}
+	}
+	return modTimes
+}
+
+// modTime returns the modification time of a file, or a zero time if the
+// file can't be accessed.
+func modTime(path string) time.Time {
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,54 @@
+package sgl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+func TestProgramWatcherStages(t *testing.T) {
+	w := &ProgramWatcher{
+		VertPath: "obj.vert",
+		FragPath: "obj.frag",
+		StagePaths: map[uint32]string{
+			gl.GEOMETRY_SHADER:        "obj.geom",
+			gl.TESS_EVALUATION_SHADER: "obj.tese",
+			gl.TESS_CONTROL_SHADER:    "obj.tesc",
+		},
+	}
+	want := []watcherStage{
+		{gl.VERTEX_SHADER, "obj.vert"},
+		{gl.GEOMETRY_SHADER, "obj.geom"},
+		{gl.TESS_EVALUATION_SHADER, "obj.tese"},
+		{gl.TESS_CONTROL_SHADER, "obj.tesc"},
+		{gl.FRAGMENT_SHADER, "obj.frag"},
+	}
+	if got := w.stages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got stages %v, want %v", got, want)
+	}
+}
+
+func TestProgramWatcherModTimes(t *testing.T) {
+	dir := t.TempDir()
+	vert := filepath.Join(dir, "obj.vert")
+	if err := os.WriteFile(vert, []byte("void main() {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(vert, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	w := &ProgramWatcher{VertPath: vert, FragPath: filepath.Join(dir, "missing.frag")}
+	modTimes := w.getModTimes()
+	if len(modTimes) != 2 || !modTimes[vert].Equal(mtime) {
+		t.Errorf("got vertex shader time %v, want %v", modTimes[vert], mtime)
+	}
+	// a file that can't be accessed has a zero time
+	if !modTimes[w.FragPath].IsZero() {
+		t.Errorf("got missing file time %v, want zero", modTimes[w.FragPath])
+	}
+}
@@ -1,11 +1,8 @@
 package sgl
 
 import (
-	"fmt"
-	"io/ioutil"
 	"log"
 	"runtime"
-	"strings"
 
 	"github.com/go-gl/gl/all-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -19,6 +16,8 @@ func Init(windowWidth int, windowHeight int, windowTitle string) *glfw.Window {
 }
 
 func Terminate() {
+	deleteSharedUniformBuffers()
+	deleteTextures()
 	glfw.Terminate()
 }
 
@@ -48,81 +47,6 @@ func InitGlfwAndOpenGL(width int, height int, title string) *glfw.Window {
 	return window
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func MakeProgram(vertexShaderSource, fragmentShaderSource string) uint32 {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		panic(err)
-	}
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		panic(err)
-	}
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-	gl.UseProgram(program)
-
-	return program
-}
-
-func MakeProgramFromFile(vertPath string, fragPath string) uint32 {
-	b, err := ioutil.ReadFile(vertPath)
-	if err != nil {
-		fmt.Print(err)
-	}
-
-	vShader := fmt.Sprintf("%s\x00", string(b))
-
-	b, err = ioutil.ReadFile(fragPath)
-	if err != nil {
-		fmt.Print(err)
-	}
-
-	fShader := fmt.Sprintf("%s\x00", string(b))
-
-	return MakeProgram(vShader, fShader)
-}
-
 func BeforeMainLoop(window *glfw.Window, vp *Viewpoint) {
 	gl.Enable(gl.DEPTH_TEST)
 	gl.DepthFunc(gl.LESS)
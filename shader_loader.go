@@ -0,0 +1,532 @@
+package sgl
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// ShaderLoader loads shader sources and preprocesses them before they're
+// compiled:
+//
+//   - `#include "file"` is replaced by the content of the file. The path is
+//     relative to the file that includes it, and the files whose names start
+//     with "sgl/" are the built-in includes (see BuiltinShaderIncludes).
+//     Every file is expanded only once, so a file could be included by
+//     several files without declaring its variables twice.
+//   - Defines are injected as "#define NAME VALUE" right after the
+//     "#version" line.
+//
+// The lines of the output are mapped to the lines of the original files,
+// so the *ShaderError of the compiled shader refers to the right files
+// and lines.
+type ShaderLoader struct {
+	// FS is the file system where the files are read from, e.g. an
+	// embed.FS. The files are read from the disk if it's nil.
+	FS fs.FS
+
+	// Defines are the macros injected into every loaded shader.
+	Defines map[string]string
+}
+
+// NewShaderLoader returns a ShaderLoader that reads files from fsys.
+// Use nil to read files from the disk.
+func NewShaderLoader(fsys fs.FS) *ShaderLoader {
+	return &ShaderLoader{FS: fsys, Defines: map[string]string{}}
+}
+
+// ShaderLineRef refers to a line of a shader file.
+type ShaderLineRef struct {
+	File string
+	Line int
+}
+
+// Load reads a shader file and preprocesses it.
+func (l *ShaderLoader) Load(shaderType uint32, name string) (ShaderSource, error) {
+	source, err := l.readFile(name)
+	if err != nil {
+		return ShaderSource{}, err
+	}
+	return l.Preprocess(shaderType, source, name)
+}
+
+// Preprocess preprocesses the source of a shader. name is the file name of
+// the source, which is used to resolve the relative includes and to map the
+// lines. It could be empty if the source is not read from a file.
+func (l *ShaderLoader) Preprocess(shaderType uint32, source string, name string) (ShaderSource, error) {
+	p := &shaderPreprocessor{loader: l, included: map[string]bool{}}
+	if err := p.process(strings.TrimRight(source, "\x00"), name, nil); err != nil {
+		return ShaderSource{}, err
+	}
+	if !p.injected {
+		// no "#version", inject the defines at the beginning
+		p.lines = append(p.defineLines(), p.lines...)
+		p.lineMap = append(make([]ShaderLineRef, len(l.Defines)), p.lineMap...)
+	}
+	return ShaderSource{
+		Type:    shaderType,
+		Source:  strings.Join(p.lines, "\n"),
+		File:    name,
+		LineMap: p.lineMap,
+		Files:   p.files,
+	}, nil
+}
+
+// shaderPreprocessor keeps the state of a Preprocess() call.
+type shaderPreprocessor struct {
+	loader   *ShaderLoader
+	lines    []string
+	lineMap  []ShaderLineRef
+	files    []string
+	included map[string]bool
+	injected bool
+}
+
+// process appends the lines of source to the output, unless the file has
+// been expanded. stack is the chain of files that include source, which is
+// used to detect recursive includes.
+func (p *shaderPreprocessor) process(source string, name string, stack []string) error {
+	for _, s := range stack {
+		if s == name {
+			return fmt.Errorf("%v is included recursively", name)
+		}
+	}
+	if p.included[name] {
+		return nil
+	}
+	p.included[name] = true
+	p.addFile(name)
+	stack = append(stack, name)
+
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#include") {
+			inc, err := parseShaderInclude(trimmed)
+			if err != nil {
+				return fmt.Errorf("%v:%v: %w", name, i+1, err)
+			}
+			incName := p.loader.resolve(name, inc)
+			incSource, err := p.loader.readFile(incName)
+			if err != nil {
+				return fmt.Errorf("%v:%v: %w", name, i+1, err)
+			}
+			if err := p.process(incSource, incName, stack); err != nil {
+				return err
+			}
+			continue
+		}
+		p.lines = append(p.lines, line)
+		p.lineMap = append(p.lineMap, ShaderLineRef{File: name, Line: i + 1})
+		if !p.injected && strings.HasPrefix(trimmed, "#version") {
+			p.lines = append(p.lines, p.defineLines()...)
+			p.lineMap = append(p.lineMap, make([]ShaderLineRef, len(p.loader.Defines))...)
+			p.injected = true
+		}
+	}
+	return nil
+}
+
+// addFile adds a file that the source is made from. The built-in includes
+// and the sources that are not read from files are skipped.
+func (p *shaderPreprocessor) addFile(name string) {
+	if name == "" || strings.HasPrefix(name, "sgl/") {
+		return
+	}
+	p.files = append(p.files, name)
+}
+
+// defineLines returns the "#define" lines of the defines, sorted by name.
+func (p *shaderPreprocessor) defineLines() []string {
+	names := []string{}
+	for name := range p.loader.Defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{}
+	for _, name := range names {
+		lines = append(lines, strings.TrimSpace("#define "+name+" "+p.loader.Defines[name]))
+	}
+	return lines
+}
+
+// parseShaderInclude parses `#include "file"` or `#include <file>`.
+func parseShaderInclude(line string) (string, error) {
+	arg := strings.TrimSpace(strings.TrimPrefix(line, "#include"))
+	if len(arg) > 2 &&
+		((arg[0] == '"' && arg[len(arg)-1] == '"') ||
+			(arg[0] == '<' && arg[len(arg)-1] == '>')) {
+		return arg[1 : len(arg)-1], nil
+	}
+	return "", fmt.Errorf("invalid #include %v", arg)
+}
+
+// resolve returns the name of the file included by the file "from".
+func (l *ShaderLoader) resolve(from string, inc string) string {
+	if strings.HasPrefix(inc, "sgl/") {
+		return inc
+	}
+	if l.FS != nil {
+		return path.Join(path.Dir(from), inc)
+	}
+	if filepath.IsAbs(inc) {
+		return inc
+	}
+	return filepath.Join(filepath.Dir(from), inc)
+}
+
+// readFile reads a built-in include, or a file from FS or the disk.
+func (l *ShaderLoader) readFile(name string) (string, error) {
+	if strings.HasPrefix(name, "sgl/") {
+		if source, ok := BuiltinShaderIncludes[name]; ok {
+			return source, nil
+		}
+		return "", fmt.Errorf("built-in include %v doesn't exist", name)
+	}
+	var b []byte
+	var err error
+	if l.FS != nil {
+		b, err = fs.ReadFile(l.FS, strings.TrimPrefix(path.Clean(name), "./"))
+	} else {
+		b, err = ioutil.ReadFile(name)
+	}
+	return string(b), err
+}
+
+// BuiltinShaderIncludes are the shader codes shared by the built-in objects,
+// which could also be included by the shaders of custom objects, e.g.
+// `#include "sgl/phong.glsl"`.
+var BuiltinShaderIncludes = map[string]string{
+	// viewpoint.glsl declares the std140 uniform block of Viewpoint, which
+	// is shared by all programs and updated by UniformBinder.
+	"sgl/viewpoint.glsl": `
+layout(std140) uniform Viewpoint {
+	mat4 projection;
+	mat4 camera;
+	vec3 viewPos;
+};
+`,
+
+	// light_src.glsl declares the std140 uniform block of LightSrc, which
+	// is shared by all programs and updated by UniformBinder.
+	"sgl/light_src.glsl": `
+layout(std140) uniform LightSrc {
+	vec3 lightPos;
+	vec3 lightColor;
+	float lightIntensity;
+};
+`,
+
+	// transform.glsl declares the uniform variables that transform the
+	// local coordinates into the clip-space coordinates.
+	"sgl/transform.glsl": `
+#include "sgl/viewpoint.glsl"
+uniform mat4 model;
+`,
+
+	// light_set.glsl declares the std140 uniform block of LightSet, which
+	// is shared by all programs and updated by UniformBinder.
+	"sgl/light_set.glsl": fmt.Sprintf(`
+#define SGL_MAX_LIGHTS %v
+#define SGL_POINT_LIGHT %v
+#define SGL_DIRECTIONAL_LIGHT %v
+#define SGL_SPOT_LIGHT %v
+
+struct Light {
+	int type;
+	vec3 pos;
+	vec3 direction;
+	vec3 color;
+	float intensity;
+	float constant;
+	float linear;
+	float quadratic;
+	float cutOff;
+	float outerCutOff;
+};
+
+layout(std140) uniform LightSet {
+	Light lights[SGL_MAX_LIGHTS];
+	int lightCount;
+};
+
+// lightDirection returns the direction from the fragment to the light,
+// and the attenuation of the light at the fragment.
+vec3 lightDirection(Light light, vec3 fragPos, out float attenuation) {
+	attenuation = 1.0;
+	if (light.type == SGL_DIRECTIONAL_LIGHT) {
+		return normalize(-light.direction);
+	}
+	float distance = length(light.pos - fragPos);
+	vec3 lightDir = normalize(light.pos - fragPos);
+	attenuation = 1.0 / (light.constant + light.linear * distance +
+		light.quadratic * distance * distance);
+	if (light.type == SGL_SPOT_LIGHT) {
+		// fade out between the inner and the outer cones
+		float theta = dot(lightDir, normalize(-light.direction));
+		float epsilon = max(light.cutOff - light.outerCutOff, 0.0001);
+		attenuation *= clamp((theta - light.outerCutOff) / epsilon, 0.0, 1.0);
+	}
+	return lightDir;
+}
+`, MaxLights, PointLight, DirectionalLight, SpotLight),
+
+	// material.glsl declares the uniform variables of Material.
+	"sgl/material.glsl": `
+uniform vec3 materialAmbient;
+uniform vec3 materialDiffuse;
+uniform vec3 materialSpecular;
+uniform float materialShininess;
+uniform float materialReflectivity;
+`,
+
+	// phong.glsl declares the uniform variables of Viewpoint, LightSrc and
+	// Material, and phong() which returns the color of a fragment lit by the
+	// light source with the Phong reflection model.
+	"sgl/phong.glsl": `
+#include "sgl/viewpoint.glsl"
+#include "sgl/light_src.glsl"
+#include "sgl/material.glsl"
+
+vec3 phong(vec3 normal, vec3 fragPos, vec3 objectColor) {
+	// ambient
+	vec3 ambient = lightColor * materialAmbient;
+
+	// diffuse
+	vec3 norm = normalize(normal);
+	vec3 lightDir = normalize(lightPos - fragPos);
+	float diff = max(dot(norm, lightDir), 0.0);
+	vec3 diffuse = (lightIntensity * lightColor) * (diff * materialDiffuse);
+
+	// specular
+	vec3 viewDir = normalize(viewPos - fragPos);
+	vec3 reflectDir = reflect(-lightDir, norm);
+	float spec = pow(max(dot(viewDir, reflectDir), 0.0), materialShininess);
+	vec3 specular = lightColor * (spec * materialSpecular);
+
+	return (ambient + diffuse + specular) * objectColor;
+}
+`,
+
+	// shadow.glsl declares the uniform variables of ShadowMap, and shadow()
+	// which returns how much the fragment is in the shadow, from 0 (lit) to
+	// 1 (in the shadow). lightDir is the direction from the fragment to the
+	// light, which is used to scale the bias. shadowAt() is the same, but
+	// takes the bias directly for the fragments without normals.
+	"sgl/shadow.glsl": `
+uniform bool shadowEnabled;
+uniform sampler2D shadowMap;
+uniform mat4 lightSpace;
+uniform float shadowBias;
+uniform int shadowPCF;
+uniform int shadowLight;
+
+float shadowAt(vec3 fragPos, float bias) {
+	if (!shadowEnabled) {
+		return 0.0;
+	}
+	vec4 lightSpacePos = lightSpace * vec4(fragPos, 1.0);
+	vec3 coord = lightSpacePos.xyz / lightSpacePos.w * 0.5 + 0.5;
+	if (coord.z > 1.0) {
+		return 0.0;
+	}
+
+	// percentage-closer filtering
+	vec2 texelSize = 1.0 / vec2(textureSize(shadowMap, 0));
+	float result = 0.0;
+	for (int x = -shadowPCF; x <= shadowPCF; x++) {
+		for (int y = -shadowPCF; y <= shadowPCF; y++) {
+			float depth = texture(shadowMap, coord.xy + vec2(x, y) * texelSize).r;
+			result += coord.z - bias > depth ? 1.0 : 0.0;
+		}
+	}
+	float n = float(2 * shadowPCF + 1);
+	return result / (n * n);
+}
+
+float shadow(vec3 fragPos, vec3 normal, vec3 lightDir) {
+	float slope = 1.0 - max(dot(normalize(normal), lightDir), 0.0);
+	return shadowAt(fragPos, shadowBias * (1.0 + 4.0 * slope));
+}
+`,
+
+	// environment.glsl declares the uniform variables of the cube map that
+	// the objects reflect, and environment() which mixes the color of a
+	// fragment with the reflection by materialReflectivity. It should be
+	// included after "sgl/viewpoint.glsl" and "sgl/material.glsl".
+	"sgl/environment.glsl": `
+uniform bool envEnabled;
+uniform samplerCube envMap;
+
+vec3 environment(vec3 color, vec3 normal, vec3 fragPos) {
+	if (!envEnabled) {
+		return color;
+	}
+	vec3 reflectDir = reflect(normalize(fragPos - viewPos), normalize(normal));
+	vec3 reflection = texture(envMap, reflectDir).rgb;
+	return mix(color, reflection, materialReflectivity);
+}
+`,
+
+	// phong_lights.glsl declares the uniform variables of Viewpoint, LightSet,
+	// Material, ShadowMap and the environment, and phongLights() which
+	// returns the color of a fragment lit by all the lights of the LightSet
+	// with the Phong reflection model, where the light of the ShadowMap casts
+	// shadows and the environment is reflected.
+	"sgl/phong_lights.glsl": `
+#include "sgl/viewpoint.glsl"
+#include "sgl/light_set.glsl"
+#include "sgl/material.glsl"
+#include "sgl/shadow.glsl"
+#include "sgl/environment.glsl"
+
+vec3 phongLight(Light light, vec3 norm, vec3 fragPos, vec3 viewDir, bool castsShadow) {
+	float attenuation;
+	vec3 lightDir = lightDirection(light, fragPos, attenuation);
+
+	// diffuse
+	float diff = max(dot(norm, lightDir), 0.0);
+	vec3 diffuse = (light.intensity * light.color) * (diff * materialDiffuse);
+
+	// specular
+	vec3 reflectDir = reflect(-lightDir, norm);
+	float spec = pow(max(dot(viewDir, reflectDir), 0.0), materialShininess);
+	vec3 specular = light.color * (spec * materialSpecular);
+
+	if (castsShadow) {
+		attenuation *= 1.0 - shadow(fragPos, norm, lightDir);
+	}
+
+	return (diffuse + specular) * attenuation;
+}
+
+vec3 phongLights(vec3 normal, vec3 fragPos, vec3 objectColor) {
+	vec3 norm = normalize(normal);
+	vec3 viewDir = normalize(viewPos - fragPos);
+	int count = min(lightCount, SGL_MAX_LIGHTS);
+	vec3 ambientColor = vec3(0.0);
+	vec3 result = vec3(0.0);
+	for (int i = 0; i < count; i++) {
+		ambientColor += lights[i].color;
+		result += phongLight(lights[i], norm, fragPos, viewDir, i == shadowLight);
+	}
+
+	// ambient, added once with the average color of the lights
+	vec3 ambient = vec3(0.0);
+	if (count > 0) {
+		ambient = ambientColor / float(count) * materialAmbient;
+	}
+	return environment((ambient + result) * objectColor, norm, fragPos);
+}
+`,
+
+	// pbr.glsl declares the uniform variables of Viewpoint, LightSet and
+	// ShadowMap, and pbrLights() which returns the color of a fragment lit
+	// by all the lights of the LightSet with the Cook-Torrance BRDF.
+	"sgl/pbr.glsl": `
+#include "sgl/viewpoint.glsl"
+#include "sgl/light_set.glsl"
+#include "sgl/shadow.glsl"
+
+const float PI = 3.14159265359;
+
+// distributionGGX is the normal distribution function of Trowbridge-Reitz GGX.
+float distributionGGX(float NdotH, float roughness) {
+	float a = roughness * roughness;
+	float a2 = a * a;
+	float denom = NdotH * NdotH * (a2 - 1.0) + 1.0;
+	return a2 / (PI * denom * denom);
+}
+
+// geometrySmith is the geometry function of Smith with Schlick-GGX.
+float geometrySmith(float NdotV, float NdotL, float roughness) {
+	float r = roughness + 1.0;
+	float k = r * r / 8.0;
+	float ggxV = NdotV / (NdotV * (1.0 - k) + k);
+	float ggxL = NdotL / (NdotL * (1.0 - k) + k);
+	return ggxV * ggxL;
+}
+
+// fresnelSchlick is the Fresnel equation of Schlick.
+vec3 fresnelSchlick(float cosTheta, vec3 F0) {
+	return F0 + (1.0 - F0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+}
+
+vec3 pbrLight(
+	Light light, vec3 N, vec3 V, vec3 fragPos,
+	vec3 albedo, float metallic, float roughness, vec3 F0, bool castsShadow
+) {
+	float attenuation;
+	vec3 L = lightDirection(light, fragPos, attenuation);
+	vec3 H = normalize(V + L);
+	float NdotL = max(dot(N, L), 0.0);
+	float NdotV = max(dot(N, V), 0.0);
+
+	// the intensity is scaled by PI, so a white diffuse surface facing a
+	// light of intensity 1 is as bright as the Phong objects
+	vec3 radiance = light.color * light.intensity * attenuation * PI;
+	if (castsShadow) {
+		radiance *= 1.0 - shadow(fragPos, N, L);
+	}
+
+	float D = distributionGGX(max(dot(N, H), 0.0), roughness);
+	float G = geometrySmith(NdotV, NdotL, roughness);
+	vec3 F = fresnelSchlick(max(dot(H, V), 0.0), F0);
+	vec3 specular = D * G * F / (4.0 * NdotV * NdotL + 0.0001);
+	vec3 kD = (vec3(1.0) - F) * (1.0 - metallic);
+
+	return (kD * albedo / PI + specular) * radiance * NdotL;
+}
+
+// pbrLights returns the color of the fragment in linear space.
+vec3 pbrLights(
+	vec3 normal, vec3 fragPos,
+	vec3 albedo, float metallic, float roughness, float ao, vec3 emissive
+) {
+	vec3 N = normalize(normal);
+	vec3 V = normalize(viewPos - fragPos);
+	vec3 F0 = mix(vec3(0.04), albedo, metallic);
+	roughness = clamp(roughness, 0.04, 1.0);
+
+	vec3 result = vec3(0.0);
+	for (int i = 0; i < lightCount && i < SGL_MAX_LIGHTS; i++) {
+		result += pbrLight(
+			lights[i], N, V, fragPos, albedo, metallic, roughness, F0, i == shadowLight
+		);
+	}
+	vec3 ambient = vec3(0.03) * albedo * ao;
+	return ambient + result + emissive;
+}
+
+// linearToSrgb converts a color in linear space to sRGB for the framebuffer.
+vec3 linearToSrgb(vec3 color) {
+	return pow(clamp(color, 0.0, 1.0), vec3(1.0 / 2.2));
+}
+`,
+}
+
+// makeBuiltinProgram makes the program of a built-in object, whose shaders
+// could include BuiltinShaderIncludes. It panics like MakeProgram()
+// because the built-in shaders are known to be valid.
+func makeBuiltinProgram(vertSource string, fragSource string) uint32 {
+	loader := NewShaderLoader(nil)
+	vs, err := loader.Preprocess(gl.VERTEX_SHADER, vertSource, "")
+	if err != nil {
+		panic(err)
+	}
+	fs, err := loader.Preprocess(gl.FRAGMENT_SHADER, fragSource, "")
+	if err != nil {
+		panic(err)
+	}
+	program, err := NewProgramWithShaders(vs, fs)
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
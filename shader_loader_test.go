@@ -0,0 +1,203 @@
+package sgl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+func TestShaderLoaderPreprocess(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shaders/obj.frag": {Data: []byte(
+			"#version 330 core\n#include \"light.glsl\"\nvoid main() {}\n",
+		)},
+		"shaders/light.glsl": {Data: []byte("uniform vec3 lightPos;\nuniform vec3 lightColor;\n")},
+		"shaders/bad.frag":   {Data: []byte("#include light.glsl\n")},
+		"shaders/lost.frag":  {Data: []byte("#version 330\n\n#include \"missing.glsl\"\n")},
+		"shaders/loop.frag":  {Data: []byte("#include \"loop.glsl\"\n")},
+		"shaders/loop.glsl":  {Data: []byte("#include \"loop.frag\"\n")},
+	}
+	tests := []struct {
+		name    string
+		file    string
+		defines map[string]string
+		want    string
+		lineMap []ShaderLineRef
+		err     string
+	}{
+		{
+			name: "include",
+			file: "shaders/obj.frag",
+			want: "#version 330 core\nuniform vec3 lightPos;\nuniform vec3 lightColor;\n\nvoid main() {}\n",
+			lineMap: []ShaderLineRef{
+				{"shaders/obj.frag", 1},
+				{"shaders/light.glsl", 1},
+				{"shaders/light.glsl", 2},
+				{"shaders/light.glsl", 3},
+				{"shaders/obj.frag", 3},
+				{"shaders/obj.frag", 4},
+			},
+		},
+		{
+			name:    "defines after version",
+			file:    "shaders/obj.frag",
+			defines: map[string]string{"MAX_LIGHTS": "4", "USE_SHADOW": ""},
+			want: "#version 330 core\n#define MAX_LIGHTS 4\n#define USE_SHADOW\n" +
+				"uniform vec3 lightPos;\nuniform vec3 lightColor;\n\nvoid main() {}\n",
+			lineMap: []ShaderLineRef{
+				{"shaders/obj.frag", 1},
+				{},
+				{},
+				{"shaders/light.glsl", 1},
+				{"shaders/light.glsl", 2},
+				{"shaders/light.glsl", 3},
+				{"shaders/obj.frag", 3},
+				{"shaders/obj.frag", 4},
+			},
+		},
+		{
+			name:    "defines without version",
+			file:    "shaders/light.glsl",
+			defines: map[string]string{"MAX_LIGHTS": "4"},
+			want:    "#define MAX_LIGHTS 4\nuniform vec3 lightPos;\nuniform vec3 lightColor;\n",
+			lineMap: []ShaderLineRef{
+				{},
+				{"shaders/light.glsl", 1},
+				{"shaders/light.glsl", 2},
+				{"shaders/light.glsl", 3},
+			},
+		},
+		{
+			name: "invalid include",
+			file: "shaders/bad.frag",
+			err:  "shaders/bad.frag:1: invalid #include light.glsl",
+		},
+		{
+			name: "missing include",
+			file: "shaders/lost.frag",
+			err:  "shaders/lost.frag:3: open shaders/missing.glsl",
+		},
+		{
+			name: "recursive include",
+			file: "shaders/loop.frag",
+			err:  "shaders/loop.frag is included recursively",
+		},
+	}
+	for _, tt := range tests {
+		loader := NewShaderLoader(fsys)
+		for name, value := range tt.defines {
+			loader.Defines[name] = value
+		}
+		src, err := loader.Load(gl.FRAGMENT_SHADER, tt.file)
+		if tt.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("%v: got error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if src.Source != tt.want {
+			t.Errorf("%v: got source %q, want %q", tt.name, src.Source, tt.want)
+		}
+		if !reflect.DeepEqual(src.LineMap, tt.lineMap) {
+			t.Errorf("%v: got line map %v, want %v", tt.name, src.LineMap, tt.lineMap)
+		}
+		if src.Type != gl.FRAGMENT_SHADER || src.File != tt.file {
+			t.Errorf("%v: got type 0x%x and file %q", tt.name, src.Type, src.File)
+		}
+	}
+}
+
+func TestShaderLoaderIncludeOnce(t *testing.T) {
+	fsys := fstest.MapFS{
+		"obj.frag": {Data: []byte(
+			"#version 330\n#include \"a.glsl\"\n#include \"b.glsl\"\n#include \"a.glsl\"\nvoid main() {}\n",
+		)},
+		"a.glsl": {Data: []byte("#include \"sgl/transform.glsl\"\nfloat a;\n")},
+		"b.glsl": {Data: []byte("#include \"sgl/transform.glsl\"\n#include \"a.glsl\"\nfloat b;\n")},
+	}
+	src, err := NewShaderLoader(fsys).Load(gl.FRAGMENT_SHADER, "obj.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range []string{"float a;", "float b;", "uniform mat4 model;"} {
+		if n := strings.Count(src.Source, decl); n != 1 {
+			t.Errorf("got %q %v times, want once", decl, n)
+		}
+	}
+	if len(src.LineMap) != len(strings.Split(src.Source, "\n")) {
+		t.Errorf("got %v mapped lines for %v lines", len(src.LineMap), len(strings.Split(src.Source, "\n")))
+	}
+}
+
+func TestShaderLoaderFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shaders/obj.frag": {Data: []byte(
+			"#version 330\n#include \"lighting.glsl\"\n#include \"common/util.glsl\"\n" +
+				"#include \"sgl/phong.glsl\"\nvoid main() {}\n",
+		)},
+		"shaders/lighting.glsl":    {Data: []byte("#include \"common/util.glsl\"\nvec3 light;\n")},
+		"shaders/common/util.glsl": {Data: []byte("float util;\n")},
+	}
+	loader := NewShaderLoader(fsys)
+	src, err := loader.Load(gl.FRAGMENT_SHADER, "shaders/obj.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"shaders/obj.frag", "shaders/lighting.glsl", "shaders/common/util.glsl"}
+	if fmt.Sprint(src.Files) != fmt.Sprint(want) {
+		t.Errorf("got files %v, want %v", src.Files, want)
+	}
+
+	src, err = loader.Preprocess(gl.FRAGMENT_SHADER, "#version 330\nvoid main() {}\n", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Files != nil {
+		t.Errorf("got files %v for a source without includes, want nil", src.Files)
+	}
+}
+
+func TestParseShaderInclude(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{`#include "light.glsl"`, "light.glsl", true},
+		{`#include   <sgl/phong.glsl>`, "sgl/phong.glsl", true},
+		{`#include light.glsl`, "", false},
+		{`#include ""`, "", false},
+		{`#include "light.glsl>`, "", false},
+	}
+	for _, tt := range tests {
+		got, err := parseShaderInclude(tt.line)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("parseShaderInclude(%q) = %q, %v", tt.line, got, err)
+		}
+	}
+}
+
+func TestShaderErrorLineMap(t *testing.T) {
+	lineMap := []ShaderLineRef{
+		{"obj.frag", 1},
+		{},
+		{"light.glsl", 1},
+		{"obj.frag", 3},
+	}
+	source := "#version 330\n#define N 4\nuniform vec3 lightPos\nvoid main() {}"
+	e := newShaderError("fragment", "obj.frag", source, lineMap, "ERROR: 0:3: '' : syntax error\n")
+	want := []ShaderErrorLine{
+		{File: "light.glsl", Line: 1, Msg: "'' : syntax error", Source: "uniform vec3 lightPos"},
+	}
+	if !reflect.DeepEqual(e.Lines, want) {
+		t.Errorf("got lines %q, want %q", e.Lines, want)
+	}
+}
@@ -0,0 +1,248 @@
+package sgl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ShadowMapUnit is the texture unit of the depth texture of ShadowMap,
+// which is far from the units used by the textures of the objects.
+const ShadowMapUnit int32 = 15
+
+// ShadowCaster is an object that casts shadows into a ShadowMap.
+// The objects embedding BaseObj and the groups are shadow casters.
+type ShadowCaster interface {
+	// DrawShadow draws the triangles of the object with the depth program
+	// of the shadow map. modelLocation is the location of the "model"
+	// uniform variable of the depth program.
+	DrawShadow(modelLocation int32)
+}
+
+// ShadowMap keeps the depth of the scene seen from a light, so the objects
+// could tell whether the fragments are in the shadow of the light. The light
+// is seen by an orthographic projection if it's a directional light, or by a
+// perspective projection if it's a point light or a spot light.
+//
+// Set it to the Shadow of SimpleObjVar to make the object receive shadows.
+// Custom objects could include "sgl/shadow.glsl" and call BindTexture()
+// before drawing, and shadow() tells how much a fragment is in the shadow.
+type ShadowMap struct {
+	// LightSpace transforms the world-space coordinates into the clip-space
+	// coordinates of the light. It's set by Update().
+	LightSpace mgl32.Mat4 `sgl:"lightSpace"`
+
+	// Bias is the depth bias that avoids shadow acne. The bias is scaled
+	// up on the surfaces facing away from the light.
+	Bias float32 `sgl:"shadowBias"`
+
+	// PCF is the radius of the percentage-closer filtering kernel in texels,
+	// which softens the edges of the shadows. 0 disables the filtering.
+	PCF int32 `sgl:"shadowPCF"`
+
+	// LightIndex is the index of the light in the LightSet that casts the
+	// shadows. It's 0 for the LightSrc of the objects.
+	LightIndex int32 `sgl:"shadowLight"`
+
+	// Unit is the texture unit of the depth texture.
+	Unit int32 `sgl:"shadowMap"`
+
+	// Enabled tells the shaders to sample the shadow map.
+	Enabled bool `sgl:"shadowEnabled"`
+
+	// Size is the width and the height of the depth texture.
+	Size int32
+
+	// Fbo is the framebuffer of the depth pass.
+	Fbo uint32
+
+	// Texture is the depth texture.
+	Texture uint32
+
+	program       uint32
+	lightSpaceLoc int32
+	modelLoc      int32
+}
+
+// NewShadowMap creates a shadow map whose depth texture is size*size.
+func NewShadowMap(size int32) *ShadowMap {
+	sm := &ShadowMap{
+		Bias:    0.005,
+		PCF:     1,
+		Unit:    ShadowMapUnit,
+		Enabled: true,
+		Size:    size,
+	}
+
+	gl.GenTextures(1, &sm.Texture)
+	gl.BindTexture(gl.TEXTURE_2D, sm.Texture)
+	gl.TexImage2D(
+		gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24, size, size, 0,
+		gl.DEPTH_COMPONENT, gl.FLOAT, nil,
+	)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	// the fragments out of the shadow map are lit
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	border := []float32{1, 1, 1, 1}
+	gl.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+
+	var fbo int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fbo)
+	gl.GenFramebuffers(1, &sm.Fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, sm.Fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, sm.Texture, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(fbo))
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Sprintf("shadow map framebuffer is incomplete: 0x%x", status))
+	}
+
+	sm.program = makeBuiltinProgram(getShadowMapVS(), getShadowMapFS())
+	sm.lightSpaceLoc = gl.GetUniformLocation(sm.program, gl.Str("lightSpace\x00"))
+	sm.modelLoc = gl.GetUniformLocation(sm.program, gl.Str("model\x00"))
+
+	return sm
+}
+
+// Update renders the depth of the casters seen from the light. The sphere
+// of center and radius should contain the objects that cast and receive
+// the shadows, and it decides the range of the shadow map. It's usually
+// called once per frame before rendering the objects, or only when the
+// objects or the light move.
+func (sm *ShadowMap) Update(light Light, center mgl32.Vec3, radius float32, casters ...ShadowCaster) {
+	sm.LightSpace = shadowLightSpace(light, center, radius)
+
+	// keep the states of the main pass
+	var fbo int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fbo)
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	depthTest := gl.IsEnabled(gl.DEPTH_TEST)
+	var polygonMode [2]int32
+	gl.GetIntegerv(gl.POLYGON_MODE, &polygonMode[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, sm.Fbo)
+	gl.Viewport(0, 0, sm.Size, sm.Size)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	// push the depth away a little to reduce shadow acne
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(2, 4)
+
+	gl.UseProgram(sm.program)
+	gl.UniformMatrix4fv(sm.lightSpaceLoc, 1, false, &sm.LightSpace[0])
+	for _, caster := range casters {
+		caster.DrawShadow(sm.modelLoc)
+	}
+
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonMode(gl.FRONT_AND_BACK, uint32(polygonMode[0]))
+	if !depthTest {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(fbo))
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+}
+
+// BindTexture binds the depth texture to Unit. It should be called before
+// drawing the objects that receive the shadows.
+func (sm *ShadowMap) BindTexture() {
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(sm.Unit))
+	gl.BindTexture(gl.TEXTURE_2D, sm.Texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// Delete frees the framebuffer, the depth texture and the depth program.
+func (sm *ShadowMap) Delete() {
+	if sm.Fbo != 0 {
+		gl.DeleteFramebuffers(1, &sm.Fbo)
+	}
+	if sm.Texture != 0 {
+		gl.DeleteTextures(1, &sm.Texture)
+	}
+	if sm.program != 0 {
+		gl.DeleteProgram(sm.program)
+	}
+	sm.Fbo = 0
+	sm.Texture = 0
+	sm.program = 0
+}
+
+// shadowLightSpace returns the light-space matrix of the light that covers
+// the sphere of center and radius.
+func shadowLightSpace(light Light, center mgl32.Vec3, radius float32) mgl32.Mat4 {
+	if light.Type == DirectionalLight {
+		dir := light.Direction.Normalize()
+		eye := center.Sub(dir.Mul(2 * radius))
+		view := mgl32.LookAtV(eye, center, shadowUp(dir))
+		proj := mgl32.Ortho(-radius, radius, -radius, radius, 0, 4*radius)
+		return proj.Mul4(view)
+	}
+
+	toCenter := center.Sub(light.Pos)
+	dist := toCenter.Len()
+	dir := toCenter
+	var fovy float32
+	if light.Type == SpotLight {
+		// the cone of the spot light
+		dir = light.Direction
+		fovy = 2 * float32(math.Acos(float64(light.OuterCutOff)))
+	} else if dist > radius {
+		fovy = 2 * float32(math.Asin(float64(radius/dist)))
+	} else {
+		// the light is inside the sphere, see as much as possible
+		fovy = mgl32.DegToRad(150)
+	}
+	fovy = mgl32.Clamp(fovy*1.1, mgl32.DegToRad(1), mgl32.DegToRad(170))
+	near := float32(math.Max(float64(dist-radius), float64(radius)/1000))
+	far := dist + radius
+	view := mgl32.LookAtV(light.Pos, light.Pos.Add(dir), shadowUp(dir.Normalize()))
+	proj := mgl32.Perspective(fovy, 1, near, far)
+	return proj.Mul4(view)
+}
+
+// shadowUp returns an up vector which is not parallel to dir.
+func shadowUp(dir mgl32.Vec3) mgl32.Vec3 {
+	if math.Abs(float64(dir.Y())) > 0.99 {
+		return mgl32.Vec3{1, 0, 0}
+	}
+	return mgl32.Vec3{0, 1, 0}
+}
+
+// getShadowMapVS returns the vertex shader of the depth pass.
+// The positions of the vertices should be at location 0, which is true
+// for all the predefined vertex layouts.
+func getShadowMapVS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		layout(location = 0) in vec3 aPos;
+		uniform mat4 lightSpace;
+		uniform mat4 model;
+		void main() {
+			gl_Position = lightSpace * model * vec4(aPos, 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
+
+// getShadowMapFS returns the fragment shader of the depth pass,
+// which only writes the depth.
+func getShadowMapFS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		void main() {
+		}
+		%v`,
+		"\x00",
+	)
+}
@@ -0,0 +1,57 @@
+package sgl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestShadowLightSpace(t *testing.T) {
+	center := mgl32.Vec3{10, 20, 30}
+	radius := float32(100)
+	tests := []struct {
+		name  string
+		light Light
+	}{
+		{"directional", NewDirectionalLight(mgl32.Vec3{-1, -2, -1}, mgl32.Vec3{1, 1, 1}, 1)},
+		{"directional down", NewDirectionalLight(mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 1, 1}, 1)},
+		{"point", NewPointLight(mgl32.Vec3{300, 400, 500}, mgl32.Vec3{1, 1, 1}, 1)},
+		{"point inside", NewPointLight(mgl32.Vec3{10, 20, 80}, mgl32.Vec3{1, 1, 1}, 1)},
+		{"spot", NewSpotLight(
+			mgl32.Vec3{10, 500, 30}, mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 1, 1}, 1,
+			mgl32.DegToRad(20), mgl32.DegToRad(30),
+		)},
+	}
+	// the center and the points on the sphere that face the light
+	points := []mgl32.Vec3{center}
+	for _, d := range []mgl32.Vec3{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {0, 0, -1}} {
+		points = append(points, center.Add(d.Mul(radius*0.5)))
+	}
+	for _, tt := range tests {
+		m := shadowLightSpace(tt.light, center, radius)
+		for _, p := range points {
+			if tt.name == "point inside" && p.Sub(tt.light.Pos).Dot(center.Sub(tt.light.Pos)) <= 0 {
+				// behind the light
+				continue
+			}
+			clip := m.Mul4x1(p.Vec4(1))
+			ndc := clip.Vec3().Mul(1 / clip.W())
+			for i, v := range ndc {
+				if math.IsNaN(float64(v)) || v < -1 || v > 1 {
+					t.Errorf("%v: %v is at %v, component %v is out of the light space", tt.name, p, ndc, i)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestShadowUp(t *testing.T) {
+	for _, dir := range []mgl32.Vec3{{0, 1, 0}, {0, -1, 0}, {1, 0, 0}, {0, 0, 1}, mgl32.Vec3{1, -1, 1}.Normalize()} {
+		up := shadowUp(dir)
+		if math.Abs(float64(up.Dot(dir))) > 0.99 {
+			t.Errorf("shadowUp(%v) = %v is parallel to the direction", dir, up)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SkyboxVar is the program variable struct for Skybox.
+type SkyboxVar struct {
+	CubeMap *CubeMap
+	Vp      *Viewpoint
+}
+
+// Skybox is the Object struct that will render a cube map behind everything,
+// so the scene is surrounded by it instead of the clear color. Only the
+// rotation of the camera of the Viewpoint is used, so the skybox doesn't move
+// with the eye, and the rotation of the model rotates the skybox.
+//
+// It could be rendered before or after the other objects, since it doesn't
+// write the depth and it's drawn at the far plane. It doesn't cast shadows.
+type Skybox struct {
+	progVar SkyboxVar
+
+	BaseObj
+}
+
+// NewSkybox returns a Skybox instance with its program and vertices.
+func NewSkybox() Object {
+	obj := &Skybox{}
+	obj.SetProgram(makeBuiltinProgram(getSkyboxVS(), getSkyboxFS()))
+	obj.OwnProgram = true
+	obj.SetVertices(NewCube(2))
+	obj.SetModel(mgl32.Ident4())
+
+	return obj
+}
+
+func (obj *Skybox) GetProgVar() interface{} {
+	return obj.progVar
+}
+
+func (obj *Skybox) SetProgVar(progVar interface{}) {
+	pv, ok := progVar.(SkyboxVar)
+	if !ok {
+		panic("progVar is not a SkyboxVar")
+	}
+	obj.SetVar(pv)
+}
+
+// Var gets the program variables of the object.
+func (obj *Skybox) Var() SkyboxVar {
+	return obj.progVar
+}
+
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *Skybox) SetVar(progVar SkyboxVar) {
+	obj.progVar = progVar
+
+	obj.BindProgVar(obj.progVar)
+	gl.UseProgram(obj.Program)
+	gl.Uniform1i(gl.GetUniformLocation(obj.Program, gl.Str("skybox\x00")), 0)
+	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
+}
+
+func (obj *Skybox) Render() {
+	if obj.progVar.CubeMap == nil {
+		return
+	}
+
+	// keep the states of the other objects
+	var depthFunc int32
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	var depthMask bool
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &depthMask)
+	cullFace := gl.IsEnabled(gl.CULL_FACE)
+
+	// the depth of the skybox is 1, which passes only where nothing is drawn
+	gl.DepthFunc(gl.LEQUAL)
+	gl.DepthMask(false)
+	// the faces are seen from the inside
+	gl.Disable(gl.CULL_FACE)
+
+	gl.UseProgram(obj.Program)
+	obj.Binder.Upload(&obj.progVar)
+	obj.progVar.CubeMap.Bind(0)
+	gl.UniformMatrix4fv(obj.Uniform["model"], 1, false, &obj.Model[0])
+	gl.BindVertexArray(obj.Vao)
+	obj.Draw(gl.TRIANGLES)
+
+	if cullFace {
+		gl.Enable(gl.CULL_FACE)
+	}
+	gl.DepthMask(depthMask)
+	gl.DepthFunc(uint32(depthFunc))
+}
+
+// DrawShadow does nothing, since the skybox is infinitely far away.
+func (obj *Skybox) DrawShadow(modelLocation int32) {
+}
+
+// getSkyboxVS returns the vertex shader of Skybox
+func getSkyboxVS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+
+		layout(location = 0) in vec3 aPos;
+
+		out vec3 TexCoord;
+
+		#include "sgl/transform.glsl"
+
+		void main() {
+			TexCoord = aPos;
+			// remove the translation of the camera and the model
+			mat4 rotation = mat4(mat3(camera)) * mat4(mat3(model));
+			vec4 pos = projection * rotation * vec4(aPos, 1.0);
+			// put the skybox at the far plane
+			gl_Position = pos.xyww;
+		}
+		%v`,
+		"\x00",
+	)
+}
+
+// getSkyboxFS returns the fragment shader of Skybox
+func getSkyboxFS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		out vec4 FragColor;
+
+		in vec3 TexCoord;
+
+		uniform samplerCube skybox;
+
+		void main() {
+			FragColor = texture(skybox, TexCoord);
+		}
+		%v`,
+		"\x00",
+	)
+}
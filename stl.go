@@ -1,10 +1,18 @@
 package sgl
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
-	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 type head struct {
@@ -20,22 +28,440 @@ type triangle struct {
 	Count  uint16
 }
 
+// ErrStlTruncatedHeader is returned when a binary STL input is shorter
+// than its 84-byte header (80-byte header + 4-byte triangle count).
+var ErrStlTruncatedHeader = errors.New("stl header is truncated")
+
+// StlTriangleCountError is returned when the triangle count in the header
+// of a binary STL input doesn't match the size of the input.
+type StlTriangleCountError struct {
+	TriNum uint32
+	Size   int
+}
+
+func (e *StlTriangleCountError) Error() string {
+	return fmt.Sprintf(
+		"stl header declares %v triangles (%v bytes), but the input has %v bytes",
+		e.TriNum, 84+50*uint64(e.TriNum), e.Size,
+	)
+}
+
+// StlSyntaxError is returned when an ASCII STL input is malformed.
+type StlSyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *StlSyntaxError) Error() string {
+	return fmt.Sprintf("stl syntax error at line %v: %v", e.Line, e.Msg)
+}
+
+// StlInvalidCoordError is returned when a vertex of a triangle has a
+// NaN or infinite coordinate.
+type StlInvalidCoordError struct {
+	Triangle int
+	Vertex   [3]float32
+}
+
+func (e *StlInvalidCoordError) Error() string {
+	return fmt.Sprintf("stl triangle %v has an invalid vertex %v", e.Triangle, e.Vertex)
+}
+
+// ReadBinaryStlFile reads a binary STL file and shifts it to the center.
+// Like the other ReadBinaryStlFile readers, it prints the error and returns
+// nil if the file can't be read, and it ignores the bytes after the
+// triangles. Use ReadStlFile() to get the errors instead.
 func ReadBinaryStlFile(file string) []float32 {
-	f, err := os.Open(file)
+	triangles, err := readStlFile(file, readBinaryStlLenient)
 	if err != nil {
 		fmt.Println(err)
+		return nil
 	}
-	h := head{}
-	err = binary.Read(f, binary.LittleEndian, &h)
+	centerX, centerY, centerZ := getStlCenter(triangles)
+	return stlToVertices(triangles, centerX, centerY, centerZ)
+}
+
+// ReadBinaryStlFileRaw reads a binary STL file without shifting.
+func ReadBinaryStlFileRaw(file string) []float32 {
+	triangles, err := readStlFile(file, readBinaryStlLenient)
 	if err != nil {
+		fmt.Println(err)
 		return nil
 	}
-	triangles := make([]triangle, h.TriNum)
-	err = binary.Read(f, binary.LittleEndian, triangles)
+	return stlToVertices(triangles, 0, 0, 0)
+}
+
+// ReadBinaryStlFileWithCenter reads a binary STL file and shifts it to
+// a specific center.
+func ReadBinaryStlFileWithCenter(
+	file string,
+	centerX float32,
+	centerY float32,
+	centerZ float32,
+) []float32 {
+	triangles, err := readStlFile(file, readBinaryStlLenient)
 	if err != nil {
+		fmt.Println(err)
 		return nil
 	}
-	vertices := []float32{}
+	return stlToVertices(triangles, centerX, centerY, centerZ)
+}
+
+// ReadStlFile reads a binary or ASCII STL file and shifts it to the center.
+// The format is detected automatically, and ASCII files that contain
+// multiple solids are merged into one vertex array. It returns an error if
+// the file can't be read or it's not a valid STL file.
+func ReadStlFile(file string) ([]float32, error) {
+	triangles, err := readStlFile(file, readStl)
+	if err != nil {
+		return nil, err
+	}
+	centerX, centerY, centerZ := getStlCenter(triangles)
+	return stlToVertices(triangles, centerX, centerY, centerZ), nil
+}
+
+// ReadStlFileRaw reads a binary or ASCII STL file without shifting.
+func ReadStlFileRaw(file string) ([]float32, error) {
+	triangles, err := readStlFile(file, readStl)
+	if err != nil {
+		return nil, err
+	}
+	return stlToVertices(triangles, 0, 0, 0), nil
+}
+
+// ReadStlFileWithCenter reads a binary or ASCII STL file and shifts it
+// to a specific center.
+func ReadStlFileWithCenter(
+	file string,
+	centerX float32,
+	centerY float32,
+	centerZ float32,
+) ([]float32, error) {
+	triangles, err := readStlFile(file, readStl)
+	if err != nil {
+		return nil, err
+	}
+	return stlToVertices(triangles, centerX, centerY, centerZ), nil
+}
+
+// ReadStl reads binary or ASCII STL data from r and shifts it to the center.
+// It returns an error for truncated or corrupt data, so the caller can
+// report bad assets.
+func ReadStl(r io.Reader) ([]float32, error) {
+	triangles, err := readStlAll(r)
+	if err != nil {
+		return nil, err
+	}
+	centerX, centerY, centerZ := getStlCenter(triangles)
+	return stlToVertices(triangles, centerX, centerY, centerZ), nil
+}
+
+// ReadStlRaw reads binary or ASCII STL data from r without shifting.
+func ReadStlRaw(r io.Reader) ([]float32, error) {
+	triangles, err := readStlAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return stlToVertices(triangles, 0, 0, 0), nil
+}
+
+// ReadStlWithCenter reads binary or ASCII STL data from r and shifts it
+// to a specific center.
+func ReadStlWithCenter(
+	r io.Reader,
+	centerX float32,
+	centerY float32,
+	centerZ float32,
+) ([]float32, error) {
+	triangles, err := readStlAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return stlToVertices(triangles, centerX, centerY, centerZ), nil
+}
+
+// ReadStlWithNormal reads binary or ASCII STL data from r without shifting,
+// and keeps the facet normals of the file instead of discarding them.
+// The output vertex array contains 6 float32 values per vertex:
+// x, y, z, nx, ny, nz. If a facet normal in the file is zero, the normal
+// is computed from the counter-clockwise winding order of the triangle.
+// The output can be used by SimpleObj.SetVerticesWithNormal().
+func ReadStlWithNormal(r io.Reader) ([]float32, error) {
+	triangles, err := readStlAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return stlToVerticesWithNormal(triangles, false, mgl32.Vec3{}), nil
+}
+
+// ReadStlWithNormalAndColor is like ReadStlWithNormal, but also decodes
+// the VisCAM/SolidView facet color stored in the 16-bit attribute of
+// binary STL triangles. The output vertex array contains 9 float32 values
+// per vertex: x, y, z, nx, ny, nz, r, g, b.
+// In the VisCAM/SolidView convention, bit 15 of the attribute tells whether
+// the color is valid, and bits 10-14, 5-9 and 0-4 are the 5-bit red, green
+// and blue values. Facets without a valid color (including all the facets of
+// ASCII files) use defaultColor.
+func ReadStlWithNormalAndColor(r io.Reader, defaultColor mgl32.Vec3) ([]float32, error) {
+	triangles, err := readStlAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return stlToVerticesWithNormal(triangles, true, defaultColor), nil
+}
+
+// readStlFile reads the whole file and decodes it with parse.
+func readStlFile(file string, parse func([]byte) ([]triangle, error)) ([]triangle, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+// readStlAll reads all data from r and decodes it.
+func readStlAll(r io.Reader) ([]triangle, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return readStl(data)
+}
+
+// readStl decodes data with the binary or the ASCII parser,
+// depending on its content.
+func readStl(data []byte) ([]triangle, error) {
+	if !isAsciiStl(data) {
+		return readBinaryStl(data)
+	}
+	triangles, err := readAsciiStl(bytes.NewReader(data))
+	if err != nil && !isText(data) {
+		// a binary file whose header starts with "solid", but whose size
+		// doesn't match its triangle count
+		return readBinaryStl(data)
+	}
+	return triangles, err
+}
+
+// isAsciiStl reports whether data looks like an ASCII STL file.
+// Some exporters also start the 80-byte header of binary files with "solid",
+// so the size implied by the triangle count is checked first.
+func isAsciiStl(data []byte) bool {
+	if len(data) >= 84 {
+		triNum := binary.LittleEndian.Uint32(data[80:84])
+		if 84+50*uint64(triNum) == uint64(len(data)) {
+			return false
+		}
+	}
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+}
+
+// isText reports whether data has only printable ASCII characters and
+// whitespace. The triangle count and the coordinates of binary STL files
+// almost always have the other bytes, e.g. 0.
+func isText(data []byte) bool {
+	for _, c := range data {
+		if (c < 0x20 || c > 0x7e) && c != '\t' && c != '\n' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// readBinaryStl decodes binary STL data whose size matches the triangle
+// count, and checks the coordinates of the triangles.
+func readBinaryStl(data []byte) ([]triangle, error) {
+	triangles, err := decodeBinaryStl(data, true)
+	if err != nil {
+		return nil, err
+	}
+	return triangles, checkStlCoords(triangles)
+}
+
+// readBinaryStlLenient decodes binary STL data like the ReadBinaryStlFile
+// readers always did: the bytes after the triangles (e.g. the padding of
+// some exporters) are ignored, and the coordinates aren't checked.
+func readBinaryStlLenient(data []byte) ([]triangle, error) {
+	return decodeBinaryStl(data, false)
+}
+
+// decodeBinaryStl decodes the header and the triangles of binary STL data.
+// If exactSize is false, data could be longer than the triangles.
+func decodeBinaryStl(data []byte, exactSize bool) ([]triangle, error) {
+	if len(data) < 84 {
+		return nil, ErrStlTruncatedHeader
+	}
+	h := head{}
+	r := bytes.NewReader(data)
+	err := binary.Read(r, binary.LittleEndian, &h)
+	if err != nil {
+		return nil, err
+	}
+	size := 84 + 50*uint64(h.TriNum)
+	if size > uint64(len(data)) || exactSize && size != uint64(len(data)) {
+		return nil, &StlTriangleCountError{TriNum: h.TriNum, Size: len(data)}
+	}
+	triangles := make([]triangle, h.TriNum)
+	err = binary.Read(r, binary.LittleEndian, triangles)
+	if err != nil {
+		return nil, err
+	}
+	return triangles, nil
+}
+
+// readAsciiStl parses the ASCII STL format:
+//
+//	solid name
+//	  facet normal nx ny nz
+//	    outer loop
+//	      vertex x y z
+//	      vertex x y z
+//	      vertex x y z
+//	    endloop
+//	  endfacet
+//	endsolid name
+//
+// There could be more than one solid in a file. Every facet should be
+// inside a solid, and every solid should have at least one facet and end
+// with "endsolid", so truncated files and text that isn't STL are errors.
+func readAsciiStl(r io.Reader) ([]triangle, error) {
+	triangles := []triangle{}
+	t := triangle{}
+	inSolid := false
+	inFacet := false
+	solidFacets := 0
+	vertNum := 0
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "solid":
+			if inSolid {
+				return nil, &StlSyntaxError{Line: line, Msg: "solid inside a solid"}
+			}
+			inSolid = true
+			solidFacets = 0
+		case "facet":
+			if !inSolid {
+				return nil, &StlSyntaxError{Line: line, Msg: "facet outside a solid"}
+			}
+			if inFacet {
+				return nil, &StlSyntaxError{Line: line, Msg: "facet inside a facet"}
+			}
+			if len(fields) != 5 || fields[1] != "normal" {
+				return nil, &StlSyntaxError{Line: line, Msg: "expected \"facet normal nx ny nz\""}
+			}
+			if !parseStlVec(fields[2:], &t.Normal) {
+				return nil, &StlSyntaxError{Line: line, Msg: "invalid normal"}
+			}
+			inFacet = true
+			vertNum = 0
+		case "outer", "endloop":
+			if !inFacet {
+				return nil, &StlSyntaxError{Line: line, Msg: fields[0] + " outside a facet"}
+			}
+		case "vertex":
+			if !inFacet {
+				return nil, &StlSyntaxError{Line: line, Msg: "vertex outside a facet"}
+			}
+			if len(fields) != 4 {
+				return nil, &StlSyntaxError{Line: line, Msg: "expected \"vertex x y z\""}
+			}
+			if vertNum > 2 {
+				return nil, &StlSyntaxError{Line: line, Msg: "more than 3 vertices in a facet"}
+			}
+			vert := [3]float32{}
+			if !parseStlVec(fields[1:], &vert) {
+				return nil, &StlSyntaxError{Line: line, Msg: "invalid vertex"}
+			}
+			switch vertNum {
+			case 0:
+				t.Vert1 = vert
+			case 1:
+				t.Vert2 = vert
+			case 2:
+				t.Vert3 = vert
+			}
+			vertNum++
+		case "endfacet":
+			if !inFacet {
+				return nil, &StlSyntaxError{Line: line, Msg: "endfacet outside a facet"}
+			}
+			if vertNum != 3 {
+				return nil, &StlSyntaxError{Line: line, Msg: "facet doesn't have 3 vertices"}
+			}
+			triangles = append(triangles, t)
+			t = triangle{}
+			inFacet = false
+			solidFacets++
+		case "endsolid":
+			if !inSolid {
+				return nil, &StlSyntaxError{Line: line, Msg: "endsolid outside a solid"}
+			}
+			if inFacet {
+				return nil, &StlSyntaxError{Line: line, Msg: "unterminated facet"}
+			}
+			if solidFacets == 0 {
+				return nil, &StlSyntaxError{Line: line, Msg: "solid has no facets"}
+			}
+			inSolid = false
+		default:
+			return nil, &StlSyntaxError{Line: line, Msg: fmt.Sprintf("unexpected %q", fields[0])}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inFacet {
+		return nil, &StlSyntaxError{Line: line, Msg: "unterminated facet"}
+	}
+	if inSolid {
+		return nil, &StlSyntaxError{Line: line, Msg: "unterminated solid"}
+	}
+	if len(triangles) == 0 {
+		return nil, &StlSyntaxError{Line: line, Msg: "no solid"}
+	}
+	return triangles, checkStlCoords(triangles)
+}
+
+// parseStlVec parses 3 float strings into vec.
+func parseStlVec(fields []string, vec *[3]float32) bool {
+	for i := 0; i < 3; i++ {
+		v, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return false
+		}
+		vec[i] = float32(v)
+	}
+	return true
+}
+
+// checkStlCoords returns a StlInvalidCoordError if any vertex
+// has a NaN or infinite coordinate.
+func checkStlCoords(triangles []triangle) error {
+	for i := 0; i < len(triangles); i++ {
+		for _, vert := range [][3]float32{
+			triangles[i].Vert1,
+			triangles[i].Vert2,
+			triangles[i].Vert3,
+		} {
+			for _, v := range vert {
+				if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+					return &StlInvalidCoordError{Triangle: i, Vertex: vert}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getStlCenter returns the center of the bounding box of the triangles.
+func getStlCenter(triangles []triangle) (float32, float32, float32) {
 	min := -1 * math.MaxFloat64
 	max := math.MaxFloat64
 	maxX := min
@@ -44,7 +470,7 @@ func ReadBinaryStlFile(file string) []float32 {
 	minX := max
 	minY := max
 	minZ := max
-	for i := 0; i < int(h.TriNum); i++ {
+	for i := 0; i < len(triangles); i++ {
 		// get max
 		maxX = math.Max(maxX, float64(triangles[i].Vert1[0]))
 		maxX = math.Max(maxX, float64(triangles[i].Vert2[0]))
@@ -69,7 +495,19 @@ func ReadBinaryStlFile(file string) []float32 {
 	centerX := float32((minX + maxX) / 2)
 	centerY := float32((minY + maxY) / 2)
 	centerZ := float32((minZ + maxZ) / 2)
-	for i := 0; i < int(h.TriNum); i++ {
+	return centerX, centerY, centerZ
+}
+
+// stlToVertices turns the triangles into a vertex array which uses
+// 3 float32 values (X, Y, Z) to represent a vertex.
+func stlToVertices(
+	triangles []triangle,
+	centerX float32,
+	centerY float32,
+	centerZ float32,
+) []float32 {
+	vertices := make([]float32, 0, len(triangles)*9)
+	for i := 0; i < len(triangles); i++ {
 		// vertex 1
 		vertices = append(vertices, triangles[i].Vert1[0]-centerX)
 		vertices = append(vertices, triangles[i].Vert1[1]-centerY)
@@ -86,73 +524,55 @@ func ReadBinaryStlFile(file string) []float32 {
 	return vertices
 }
 
-func ReadBinaryStlFileRaw(file string) []float32 {
-	f, err := os.Open(file)
-	if err != nil {
-		fmt.Println(err)
+// stlToVerticesWithNormal turns the triangles into a vertex array which uses
+// 6 float32 values (X, Y, Z, NX, NY, NZ) to represent a vertex, or 9 float32
+// values (X, Y, Z, NX, NY, NZ, R, G, B) if withColor is true.
+func stlToVerticesWithNormal(
+	triangles []triangle,
+	withColor bool,
+	defaultColor mgl32.Vec3,
+) []float32 {
+	stride := 6
+	if withColor {
+		stride = 9
 	}
-	h := head{}
-	err = binary.Read(f, binary.LittleEndian, &h)
-	if err != nil {
-		return nil
-	}
-	triangles := make([]triangle, h.TriNum)
-	err = binary.Read(f, binary.LittleEndian, triangles)
-	if err != nil {
-		return nil
-	}
-	vertices := []float32{}
-	for i := 0; i < int(h.TriNum); i++ {
-		// vertex 1
-		vertices = append(vertices, triangles[i].Vert1[0])
-		vertices = append(vertices, triangles[i].Vert1[1])
-		vertices = append(vertices, triangles[i].Vert1[2])
-		// vertex 2
-		vertices = append(vertices, triangles[i].Vert2[0])
-		vertices = append(vertices, triangles[i].Vert2[1])
-		vertices = append(vertices, triangles[i].Vert2[2])
-		// vertex 3
-		vertices = append(vertices, triangles[i].Vert3[0])
-		vertices = append(vertices, triangles[i].Vert3[1])
-		vertices = append(vertices, triangles[i].Vert3[2])
+	vertices := make([]float32, 0, len(triangles)*3*stride)
+	for i := 0; i < len(triangles); i++ {
+		normal := mgl32.Vec3(triangles[i].Normal)
+		if normal.ApproxEqual(mgl32.Vec3{}) {
+			normal = faceNormal(
+				triangles[i].Vert1,
+				triangles[i].Vert2,
+				triangles[i].Vert3,
+			)
+		}
+		color := defaultColor
+		if withColor {
+			color = decodeStlColor(triangles[i].Count, defaultColor)
+		}
+		for _, vert := range [][3]float32{
+			triangles[i].Vert1,
+			triangles[i].Vert2,
+			triangles[i].Vert3,
+		} {
+			vertices = append(vertices, vert[0], vert[1], vert[2])
+			vertices = append(vertices, normal[0], normal[1], normal[2])
+			if withColor {
+				vertices = append(vertices, color[0], color[1], color[2])
+			}
+		}
 	}
 	return vertices
 }
 
-func ReadBinaryStlFileWithCenter(
-	file string,
-	centerX float32,
-	centerY float32,
-	centerZ float32,
-) []float32 {
-	f, err := os.Open(file)
-	if err != nil {
-		fmt.Println(err)
+// decodeStlColor decodes the VisCAM/SolidView 15-bit color of a facet.
+func decodeStlColor(attr uint16, defaultColor mgl32.Vec3) mgl32.Vec3 {
+	if attr&0x8000 == 0 {
+		return defaultColor
 	}
-	h := head{}
-	err = binary.Read(f, binary.LittleEndian, &h)
-	if err != nil {
-		return nil
+	return mgl32.Vec3{
+		float32((attr>>10)&0x1f) / 31,
+		float32((attr>>5)&0x1f) / 31,
+		float32(attr&0x1f) / 31,
 	}
-	triangles := make([]triangle, h.TriNum)
-	err = binary.Read(f, binary.LittleEndian, triangles)
-	if err != nil {
-		return nil
-	}
-	vertices := []float32{}
-	for i := 0; i < int(h.TriNum); i++ {
-		// vertex 1
-		vertices = append(vertices, triangles[i].Vert1[0]-centerX)
-		vertices = append(vertices, triangles[i].Vert1[1]-centerY)
-		vertices = append(vertices, triangles[i].Vert1[2]-centerZ)
-		// vertex 2
-		vertices = append(vertices, triangles[i].Vert2[0]-centerX)
-		vertices = append(vertices, triangles[i].Vert2[1]-centerY)
-		vertices = append(vertices, triangles[i].Vert2[2]-centerZ)
-		// vertex 3
-		vertices = append(vertices, triangles[i].Vert3[0]-centerX)
-		vertices = append(vertices, triangles[i].Vert3[1]-centerY)
-		vertices = append(vertices, triangles[i].Vert3[2]-centerZ)
-	}
-	return vertices
 }
@@ -0,0 +1,305 @@
+package sgl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const asciiStlTwoFacets = `solid part
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+  facet normal 0 0 1
+    outer loop
+      vertex 1 0 0
+      vertex 1 1 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid part
+`
+
+func TestReadAsciiStl(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		triangles int
+		errLine   int
+	}{
+		{
+			name:      "two facets",
+			input:     asciiStlTwoFacets,
+			triangles: 2,
+		},
+		{
+			name:      "two solids",
+			input:     asciiStlTwoFacets + asciiStlTwoFacets,
+			triangles: 4,
+		},
+		{
+			name:    "cut off in the second facet",
+			input:   asciiStlTwoFacets[:strings.Index(asciiStlTwoFacets, "vertex 1 1 0")],
+			errLine: 12,
+		},
+		{
+			name:    "missing endsolid",
+			input:   strings.TrimSuffix(asciiStlTwoFacets, "endsolid part\n"),
+			errLine: 15,
+		},
+		{
+			name:    "solid followed by garbage",
+			input:   "solid part\nhello world\n",
+			errLine: 2,
+		},
+		{
+			name:    "solid without facets",
+			input:   "solid part\nendsolid part\n",
+			errLine: 2,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			errLine: 0,
+		},
+		{
+			name:    "facet outside a solid",
+			input:   strings.TrimPrefix(asciiStlTwoFacets, "solid part\n"),
+			errLine: 1,
+		},
+		{
+			name:    "two vertices",
+			input:   strings.Replace(asciiStlTwoFacets, "      vertex 0 1 0\n", "", 1),
+			errLine: 7,
+		},
+		{
+			name:    "invalid vertex",
+			input:   strings.Replace(asciiStlTwoFacets, "vertex 1 0 0", "vertex 1 x 0", 1),
+			errLine: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triangles, err := readAsciiStl(strings.NewReader(tt.input))
+			if tt.triangles > 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(triangles) != tt.triangles {
+					t.Fatalf("got %v triangles, want %v", len(triangles), tt.triangles)
+				}
+				return
+			}
+			var syntaxErr *StlSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v triangles and error %v, want a StlSyntaxError", len(triangles), err)
+			}
+			if syntaxErr.Line != tt.errLine {
+				t.Errorf("got the error at line %v, want %v: %v", syntaxErr.Line, tt.errLine, err)
+			}
+		})
+	}
+}
+
+func TestReadAsciiStlVertices(t *testing.T) {
+	triangles, err := readAsciiStl(strings.NewReader(asciiStlTwoFacets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := stlToVertices(triangles, 0, 0, 0)
+	want := []float32{
+		0, 0, 0, 1, 0, 0, 0, 1, 0,
+		1, 0, 0, 1, 1, 0, 0, 1, 0,
+	}
+	if !equalFloats(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// binaryStl returns a binary STL file of n triangles, whose header starts
+// with header.
+func binaryStl(header string, n int) []byte {
+	buf := &bytes.Buffer{}
+	h := head{TriNum: uint32(n)}
+	copy(h.Header[:], header)
+	binary.Write(buf, binary.LittleEndian, h)
+	for i := 0; i < n; i++ {
+		binary.Write(buf, binary.LittleEndian, triangle{
+			Normal: [3]float32{0, 0, 1},
+			Vert1:  [3]float32{float32(i), 0, 0},
+			Vert2:  [3]float32{float32(i) + 1, 0, 0},
+			Vert3:  [3]float32{float32(i), 1, 0},
+		})
+	}
+	return buf.Bytes()
+}
+
+func TestReadStl(t *testing.T) {
+	bin := binaryStl("binary", 2)
+	solidBin := binaryStl("solid exported as binary", 2)
+	tests := []struct {
+		name      string
+		input     []byte
+		triangles int
+		err       error
+	}{
+		{"binary", bin, 2, nil},
+		{"binary with solid header", solidBin, 2, nil},
+		{"ascii", []byte(asciiStlTwoFacets), 2, nil},
+		{"binary with a trailing byte", append(bin, 0), 0, &StlTriangleCountError{}},
+		{"solid binary with a trailing byte", append(solidBin, 0), 0, &StlTriangleCountError{}},
+		{"truncated binary", bin[:len(bin)-1], 0, &StlTriangleCountError{}},
+		{"truncated solid binary", solidBin[:len(solidBin)-1], 0, &StlTriangleCountError{}},
+		{"truncated solid header", solidBin[:40], 0, ErrStlTruncatedHeader},
+		{"truncated header", bin[:40], 0, ErrStlTruncatedHeader},
+		{"truncated ascii", []byte(asciiStlTwoFacets[:100]), 0, &StlSyntaxError{}},
+		{"solid and garbage", []byte("solid part\nhello world\n"), 0, &StlSyntaxError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triangles, err := readStl(tt.input)
+			if !sameError(err, tt.err) {
+				t.Fatalf("got %v triangles and error %v, want error %T",
+					len(triangles), err, tt.err)
+			}
+			if len(triangles) != tt.triangles {
+				t.Fatalf("got %v triangles, want %v", len(triangles), tt.triangles)
+			}
+		})
+	}
+}
+
+// sameError reports whether err is want, or has the same type as want.
+func sameError(err, want error) bool {
+	if err == nil || want == nil {
+		return err == want
+	}
+	return errors.Is(err, want) || reflect.TypeOf(err) == reflect.TypeOf(want)
+}
+
+func TestReadStlInvalidCoord(t *testing.T) {
+	data := []byte(strings.Replace(asciiStlTwoFacets, "vertex 1 1 0", "vertex 1 nan 0", 1))
+	_, err := readStl(data)
+	var coordErr *StlInvalidCoordError
+	if !errors.As(err, &coordErr) {
+		t.Fatalf("got error %v, want a StlInvalidCoordError", err)
+	}
+	if coordErr.Triangle != 1 {
+		t.Errorf("got triangle %v, want 1", coordErr.Triangle)
+	}
+}
+
+func TestReadBinaryStlLenient(t *testing.T) {
+	bin := binaryStl("solid exported as binary", 2)
+	tests := []struct {
+		name      string
+		input     []byte
+		triangles int
+		wantErr   bool
+	}{
+		{"exact size", bin, 2, false},
+		{"trailing padding", append(bin, make([]byte, 16)...), 2, false},
+		{"truncated", bin[:len(bin)-1], 0, true},
+		{"truncated header", bin[:40], 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triangles, err := readBinaryStlLenient(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if len(triangles) != tt.triangles {
+				t.Fatalf("got %v triangles, want %v", len(triangles), tt.triangles)
+			}
+		})
+	}
+}
+
+// equalFloats reports whether a and b have exactly the same values.
+func equalFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReadStlWithNormal(t *testing.T) {
+	// the second facet has no normal, so it's computed from the winding
+	input := strings.Replace(asciiStlTwoFacets, "facet normal 0 0 1", "facet normal 0 1 0", 1)
+	input = strings.Replace(input, "facet normal 0 0 1", "facet normal 0 0 0", 1)
+	got, err := ReadStlWithNormal(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{
+		0, 0, 0, 0, 1, 0,
+		1, 0, 0, 0, 1, 0,
+		0, 1, 0, 0, 1, 0,
+		1, 0, 0, 0, 0, 1,
+		1, 1, 0, 0, 0, 1,
+		0, 1, 0, 0, 0, 1,
+	}
+	if !equalFloats(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeStlColor(t *testing.T) {
+	defaultColor := mgl32.Vec3{0.5, 0.5, 0.5}
+	tests := []struct {
+		name string
+		attr uint16
+		want mgl32.Vec3
+	}{
+		{"no color", 0x0000, defaultColor},
+		{"invalid color", 0x7fff, defaultColor},
+		{"red", 0x8000 | 0x1f<<10, mgl32.Vec3{1, 0, 0}},
+		{"green", 0x8000 | 0x1f<<5, mgl32.Vec3{0, 1, 0}},
+		{"blue", 0x8000 | 0x1f, mgl32.Vec3{0, 0, 1}},
+		{"black", 0x8000, mgl32.Vec3{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeStlColor(tt.attr, defaultColor); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadStlWithNormalAndColor(t *testing.T) {
+	data := binaryStl("binary", 2)
+	// the attribute is the last 2 bytes of a triangle
+	binary.LittleEndian.PutUint16(data[84+48:], 0x8000|0x1f<<10)
+	got, err := ReadStlWithNormalAndColor(bytes.NewReader(data), mgl32.Vec3{1, 1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2*3*9 {
+		t.Fatalf("got %v values, want %v", len(got), 2*3*9)
+	}
+	for i := 0; i < 6; i++ {
+		color := mgl32.Vec3{got[i*9+6], got[i*9+7], got[i*9+8]}
+		want := mgl32.Vec3{1, 1, 1}
+		if i < 3 {
+			want = mgl32.Vec3{1, 0, 0}
+		}
+		if color != want {
+			t.Errorf("vertex %v has color %v, want %v", i, color, want)
+		}
+	}
+}
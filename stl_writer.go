@@ -0,0 +1,108 @@
+package sgl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// WriteBinaryStl writes a vertex array which uses 3 float32 values
+// (X, Y, Z) to represent a vertex into w in the binary STL format.
+// Every three vertices form a triangle, and the facet normal is computed
+// from the counter-clockwise winding order of the triangle.
+func WriteBinaryStl(w io.Writer, vertices []float32) error {
+	return WriteBinaryStlWithModel(w, vertices, mgl32.Ident4())
+}
+
+// WriteBinaryStlWithModel is like WriteBinaryStl, but transforms
+// the vertices with model before writing them.
+func WriteBinaryStlWithModel(w io.Writer, vertices []float32, model mgl32.Mat4) error {
+	triangles, err := verticesToStl(vertices, model)
+	if err != nil {
+		return err
+	}
+	h := head{}
+	copy(h.Header[:], "binary STL written by sgl")
+	h.TriNum = uint32(len(triangles))
+	bw := bufio.NewWriter(w)
+	if err := binary.Write(bw, binary.LittleEndian, &h); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, triangles); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteAsciiStl writes a vertex array which uses 3 float32 values
+// (X, Y, Z) to represent a vertex into w in the ASCII STL format.
+// It returns an error if the vertex array is empty, because a solid
+// without facets isn't a valid ASCII STL file.
+func WriteAsciiStl(w io.Writer, vertices []float32) error {
+	return WriteAsciiStlWithModel(w, vertices, mgl32.Ident4())
+}
+
+// WriteAsciiStlWithModel is like WriteAsciiStl, but transforms
+// the vertices with model before writing them.
+func WriteAsciiStlWithModel(w io.Writer, vertices []float32, model mgl32.Mat4) error {
+	triangles, err := verticesToStl(vertices, model)
+	if err != nil {
+		return err
+	}
+	if len(triangles) == 0 {
+		return fmt.Errorf("vertex array is empty, and an ASCII STL solid needs at least one facet")
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "solid sgl")
+	for i := 0; i < len(triangles); i++ {
+		fmt.Fprintf(bw, "  facet normal %v\n", formatStlVec(triangles[i].Normal))
+		fmt.Fprintln(bw, "    outer loop")
+		fmt.Fprintf(bw, "      vertex %v\n", formatStlVec(triangles[i].Vert1))
+		fmt.Fprintf(bw, "      vertex %v\n", formatStlVec(triangles[i].Vert2))
+		fmt.Fprintf(bw, "      vertex %v\n", formatStlVec(triangles[i].Vert3))
+		fmt.Fprintln(bw, "    endloop")
+		fmt.Fprintln(bw, "  endfacet")
+	}
+	fmt.Fprintln(bw, "endsolid sgl")
+	return bw.Flush()
+}
+
+// verticesToStl transforms the vertices with model and groups them
+// into triangles with facet normals.
+func verticesToStl(vertices []float32, model mgl32.Mat4) ([]triangle, error) {
+	if len(vertices)%9 != 0 {
+		return nil, fmt.Errorf(
+			"vertex array has %v values, which is not a multiple of 9 (3 vertices of X, Y, Z)",
+			len(vertices),
+		)
+	}
+	triangles := make([]triangle, len(vertices)/9)
+	for i := 0; i < len(triangles); i++ {
+		pts := [3]mgl32.Vec3{}
+		for j := 0; j < 3; j++ {
+			k := i*9 + j*3
+			pts[j] = model.Mul4x1(
+				mgl32.Vec4{vertices[k], vertices[k+1], vertices[k+2], 1},
+			).Vec3()
+		}
+		triangles[i] = triangle{
+			Normal: faceNormal(pts[0], pts[1], pts[2]),
+			Vert1:  pts[0],
+			Vert2:  pts[1],
+			Vert3:  pts[2],
+		}
+	}
+	return triangles, nil
+}
+
+// formatStlVec formats vec with the shortest representation
+// that reads back to the same float32 values.
+func formatStlVec(vec [3]float32) string {
+	return strconv.FormatFloat(float64(vec[0]), 'e', -1, 32) + " " +
+		strconv.FormatFloat(float64(vec[1]), 'e', -1, 32) + " " +
+		strconv.FormatFloat(float64(vec[2]), 'e', -1, 32)
+}
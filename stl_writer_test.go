@@ -0,0 +1,110 @@
+package sgl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestStlRoundTrip(t *testing.T) {
+	cube := *NewCube(2)
+	odd := []float32{
+		0.1, -2.5e-7, 3.4028235e38,
+		1.0 / 3, 7, -0.0,
+		123456.79, 1e-30, -42,
+	}
+	writers := []struct {
+		name  string
+		write func(io.Writer, []float32) error
+		// rejectsEmpty tells whether the format can't have 0 triangles
+		rejectsEmpty bool
+	}{
+		{"binary", WriteBinaryStl, false},
+		{"ascii", WriteAsciiStl, true},
+	}
+	tests := []struct {
+		name     string
+		vertices []float32
+	}{
+		{"cube", cube},
+		{"odd values", odd},
+		{"empty", []float32{}},
+	}
+	for _, w := range writers {
+		for _, tt := range tests {
+			t.Run(w.name+" "+tt.name, func(t *testing.T) {
+				buf := &bytes.Buffer{}
+				err := w.write(buf, tt.vertices)
+				if len(tt.vertices) == 0 && w.rejectsEmpty {
+					if err == nil {
+						t.Fatalf("wrote %q, want an error", buf.String())
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := ReadStlRaw(buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !equalFloats(got, tt.vertices) {
+					t.Fatalf("got %v, want %v", got, tt.vertices)
+				}
+			})
+		}
+	}
+}
+
+func TestStlRoundTripWithModel(t *testing.T) {
+	cube := *NewCube(2)
+	model := mgl32.Translate3D(10, 0, 0).Mul4(mgl32.Scale3D(2, 2, 2))
+	want := make([]float32, len(cube))
+	for i := 0; i < len(cube); i += 3 {
+		v := model.Mul4x1(mgl32.Vec4{cube[i], cube[i+1], cube[i+2], 1})
+		copy(want[i:i+3], v[:3])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteBinaryStlWithModel(buf, cube, model); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadStlRaw(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalFloats(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// the facet normals are written from the winding order
+	buf.Reset()
+	if err := WriteAsciiStlWithModel(buf, cube, model); err != nil {
+		t.Fatal(err)
+	}
+	triangles, err := readStl(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tri := range triangles {
+		v1 := mgl32.Vec3(tri.Vert1)
+		v2 := mgl32.Vec3(tri.Vert2)
+		v3 := mgl32.Vec3(tri.Vert3)
+		normal := mgl32.Vec3(tri.Normal)
+		if !normal.ApproxEqual(v2.Sub(v1).Cross(v3.Sub(v1)).Normalize()) {
+			t.Fatalf("triangle %v has normal %v", i, normal)
+		}
+	}
+}
+
+func TestWriteStlInvalidVertices(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteBinaryStl(buf, make([]float32, 10)); err == nil {
+		t.Error("WriteBinaryStl() accepted 10 values")
+	}
+	if err := WriteAsciiStl(buf, make([]float32, 10)); err == nil {
+		t.Error("WriteAsciiStl() accepted 10 values")
+	}
+}
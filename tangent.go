@@ -0,0 +1,151 @@
+package sgl
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AddTangent computes the tangents of the vertices of x, y, z, nx, ny, nz,
+// u, v (e.g. ObjMesh.VerticesWithNormalAndTexCoord()), whose every three
+// vertices form a triangle, and returns the vertices of x, y, z, nx, ny, nz,
+// u, v, tx, ty, tz, tw, which is PosNormalTexTangentLayout.
+//
+// The tangents follow the convention of MikkTSpace, which is also used by
+// glTF: (tx, ty, tz) is a unit vector perpendicular to the normal pointing to
+// where u increases, and tw is 1 or -1 telling the handedness, so the
+// bitangent is tw * cross(normal, tangent). Like MikkTSpace, the tangents of
+// the triangles are weighted by their angles at the vertices and averaged
+// over the vertices with the same position, normal and texture coordinates,
+// and the vertices are never averaged across the mirrored seams of the
+// texture coordinates.
+func AddTangent(vertices []float32) []float32 {
+	if len(vertices)%24 != 0 {
+		return vertices
+	}
+	vertNum := len(vertices) / 8
+
+	// the sums of the tangents and the bitangents of the vertices sharing
+	// the same position, normal, texture coordinates and handedness
+	type tangentSum struct {
+		tangent   mgl32.Vec3
+		bitangent mgl32.Vec3
+	}
+	sums := map[tangentKey]*tangentSum{}
+	keys := make([]tangentKey, vertNum)
+
+	for i := 0; i < vertNum; i += 3 {
+		var pos [3]mgl32.Vec3
+		var uv [3]mgl32.Vec2
+		for j := 0; j < 3; j++ {
+			v := vertices[(i+j)*8:]
+			pos[j] = mgl32.Vec3{v[0], v[1], v[2]}
+			uv[j] = mgl32.Vec2{v[6], v[7]}
+		}
+		tangent, bitangent := triangleTangent(pos, uv)
+		for j := 0; j < 3; j++ {
+			v := vertices[(i+j)*8 : (i+j+1)*8]
+			normal := mgl32.Vec3{v[3], v[4], v[5]}
+			key := newTangentKey(v, tangentHandedness(normal, tangent, bitangent))
+			keys[i+j] = key
+			sum, ok := sums[key]
+			if !ok {
+				sum = &tangentSum{}
+				sums[key] = sum
+			}
+			weight := cornerAngle(pos[j], pos[(j+1)%3], pos[(j+2)%3])
+			sum.tangent = sum.tangent.Add(tangent.Mul(weight))
+			sum.bitangent = sum.bitangent.Add(bitangent.Mul(weight))
+		}
+	}
+
+	newVertices := make([]float32, 0, vertNum*12)
+	for i := 0; i < vertNum; i++ {
+		v := vertices[i*8 : (i+1)*8]
+		normal := mgl32.Vec3{v[3], v[4], v[5]}
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		}
+		sum := sums[keys[i]]
+		// Gram-Schmidt orthogonalize the tangent against the normal
+		tangent := sum.tangent.Sub(normal.Mul(normal.Dot(sum.tangent)))
+		if tangent.Len() < 1e-12 {
+			tangent = perpendicular(normal)
+		} else {
+			tangent = tangent.Normalize()
+		}
+		w := float32(1)
+		if normal.Cross(tangent).Dot(sum.bitangent) < 0 {
+			w = -1
+		}
+		newVertices = append(newVertices, v...)
+		newVertices = append(newVertices, tangent[0], tangent[1], tangent[2], w)
+	}
+	return newVertices
+}
+
+// tangentKey identifies the vertices whose tangents are averaged. It's
+// the bits of x, y, z, nx, ny, nz, u, v and the handedness.
+type tangentKey struct {
+	bits       [8]uint32
+	handedness bool
+}
+
+func newTangentKey(vertex []float32, handedness bool) tangentKey {
+	key := tangentKey{handedness: handedness}
+	for i, v := range vertex[:8] {
+		if v == 0 {
+			v = 0 // -0 and 0 are the same
+		}
+		key.bits[i] = math.Float32bits(v)
+	}
+	return key
+}
+
+// triangleTangent returns the tangent and the bitangent of the triangle,
+// which are the directions where u and v increase. They're zero vectors if
+// the texture coordinates of the triangle are degenerate.
+func triangleTangent(pos [3]mgl32.Vec3, uv [3]mgl32.Vec2) (mgl32.Vec3, mgl32.Vec3) {
+	e1 := pos[1].Sub(pos[0])
+	e2 := pos[2].Sub(pos[0])
+	d1 := uv[1].Sub(uv[0])
+	d2 := uv[2].Sub(uv[0])
+	det := d1[0]*d2[1] - d2[0]*d1[1]
+	if math.Abs(float64(det)) < 1e-12 {
+		return mgl32.Vec3{}, mgl32.Vec3{}
+	}
+	r := 1 / det
+	tangent := e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(r)
+	bitangent := e2.Mul(d1[0]).Sub(e1.Mul(d2[0])).Mul(r)
+	return tangent, bitangent
+}
+
+// tangentHandedness tells whether the tangent space is right-handed, i.e.
+// the bitangent is on the same side as cross(normal, tangent).
+func tangentHandedness(normal, tangent, bitangent mgl32.Vec3) bool {
+	return normal.Cross(tangent).Dot(bitangent) >= 0
+}
+
+// cornerAngle returns the angle of the triangle (p, a, b) at p.
+func cornerAngle(p, a, b mgl32.Vec3) float32 {
+	e1 := a.Sub(p)
+	e2 := b.Sub(p)
+	if e1.Len() == 0 || e2.Len() == 0 {
+		return 0
+	}
+	cos := mgl32.Clamp(e1.Normalize().Dot(e2.Normalize()), -1, 1)
+	return float32(math.Acos(float64(cos)))
+}
+
+// perpendicular returns a unit vector perpendicular to v.
+func perpendicular(v mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if math.Abs(float64(v.X())) > 0.9 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	p := v.Cross(axis)
+	if p.Len() == 0 {
+		return axis
+	}
+	return p.Normalize()
+}
@@ -0,0 +1,112 @@
+package sgl
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// tangentTriangle returns the triangle (0, 0, 0), (1, 0, 0), (0, 1, 0) of
+// x, y, z, nx, ny, nz, u, v with the normal and the texture coordinates.
+func tangentTriangle(normal mgl32.Vec3, uv [3]mgl32.Vec2) []float32 {
+	pos := [3]mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	vertices := []float32{}
+	for i := range pos {
+		vertices = append(vertices, pos[i][:]...)
+		vertices = append(vertices, normal[:]...)
+		vertices = append(vertices, uv[i][:]...)
+	}
+	return vertices
+}
+
+func TestAddTangent(t *testing.T) {
+	up := mgl32.Vec3{0, 0, 1}
+	tests := []struct {
+		name    string
+		normal  mgl32.Vec3
+		uv      [3]mgl32.Vec2
+		tangent mgl32.Vec4
+	}{
+		{
+			name:    "uv along x and y",
+			normal:  up,
+			uv:      [3]mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+			tangent: mgl32.Vec4{1, 0, 0, 1},
+		},
+		{
+			name:    "mirrored u",
+			normal:  up,
+			uv:      [3]mgl32.Vec2{{0, 0}, {-1, 0}, {0, 1}},
+			tangent: mgl32.Vec4{-1, 0, 0, -1},
+		},
+		{
+			name:    "rotated uv",
+			normal:  up,
+			uv:      [3]mgl32.Vec2{{0, 0}, {0, -1}, {1, 0}},
+			tangent: mgl32.Vec4{0, 1, 0, 1},
+		},
+		{
+			name:    "scaled uv",
+			normal:  up,
+			uv:      [3]mgl32.Vec2{{0, 0}, {4, 0}, {0, 0.5}},
+			tangent: mgl32.Vec4{1, 0, 0, 1},
+		},
+		{
+			name:    "tilted normal",
+			normal:  mgl32.Vec3{0.6, 0, 0.8},
+			uv:      [3]mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+			tangent: mgl32.Vec4{0.8, 0, -0.6, 1},
+		},
+		{
+			name:    "degenerate uv",
+			normal:  up,
+			uv:      [3]mgl32.Vec2{{0, 0}, {0, 0}, {0, 0}},
+			tangent: mgl32.Vec4{0, 1, 0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vertices := tangentTriangle(tt.normal, tt.uv)
+			got := AddTangent(vertices)
+			if len(got) != 3*12 {
+				t.Fatalf("got %v values, want 36", len(got))
+			}
+			for i := 0; i < 3; i++ {
+				v := got[i*12 : (i+1)*12]
+				if !equalFloats(v[:8], vertices[i*8:(i+1)*8]) {
+					t.Errorf("vertex %v is %v, want %v", i, v[:8], vertices[i*8:(i+1)*8])
+				}
+				tangent := mgl32.Vec4{v[8], v[9], v[10], v[11]}
+				if !tangent.ApproxEqualThreshold(tt.tangent, 1e-5) {
+					t.Errorf("vertex %v has tangent %v, want %v", i, tangent, tt.tangent)
+				}
+			}
+		})
+	}
+}
+
+func TestAddTangentMirroredSeam(t *testing.T) {
+	// the triangles share the vertex (0, 0, 0) with the same normal and
+	// texture coordinates, but their u increase in opposite directions
+	up := mgl32.Vec3{0, 0, 1}
+	vertices := append(
+		tangentTriangle(up, [3]mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}}),
+		tangentTriangle(up, [3]mgl32.Vec2{{0, 0}, {-1, 0}, {0, 1}})...,
+	)
+	got := AddTangent(vertices)
+	right := mgl32.Vec4{got[8], got[9], got[10], got[11]}
+	mirrored := mgl32.Vec4{got[36+8], got[36+9], got[36+10], got[36+11]}
+	if !right.ApproxEqual(mgl32.Vec4{1, 0, 0, 1}) {
+		t.Errorf("got tangent %v, want (1, 0, 0, 1)", right)
+	}
+	if !mirrored.ApproxEqual(mgl32.Vec4{-1, 0, 0, -1}) {
+		t.Errorf("got mirrored tangent %v, want (-1, 0, 0, -1)", mirrored)
+	}
+}
+
+func TestAddTangentInvalidVertices(t *testing.T) {
+	vertices := make([]float32, 16)
+	if got := AddTangent(vertices); len(got) != len(vertices) {
+		t.Errorf("got %v values, want the %v values unchanged", len(got), len(vertices))
+	}
+}
@@ -0,0 +1,146 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// TexObjVar is the program variable struct for TexObj.
+// The object is lit by all the lights of Lights, or by Ls if Lights is nil.
+// It receives the shadows of Shadow if it's not nil, and reflects Env by
+// Mt.Reflectivity if it's not nil.
+type TexObjVar struct {
+	Texture *Texture
+	Vp      *Viewpoint
+	Ls      *LightSrc
+	Lights  *LightSet
+	Mt      *Material
+	Shadow  *ShadowMap
+	Env     *CubeMap
+}
+
+// TexObj is the Object struct that will render a textured object, which is
+// lit like SimpleObj but its color comes from the texture. The lighting is
+// not gamma corrected, so the texture usually shouldn't be an sRGB texture.
+// The texture is not owned by the object, so it could be shared by many
+// objects and should be deleted by Texture.Delete() or Terminate().
+type TexObj struct {
+	progVar TexObjVar
+
+	BaseObj
+}
+
+// NewTexObj returns a TexObj instance with its program.
+func NewTexObj() Object {
+	obj := &TexObj{}
+	obj.SetProgram(makeBuiltinProgram(getTexObjVS(), getTexObjFS()))
+	obj.OwnProgram = true
+
+	return obj
+}
+
+func (obj *TexObj) GetProgVar() interface{} {
+	return obj.progVar
+}
+
+func (obj *TexObj) SetProgVar(progVar interface{}) {
+	pv, ok := progVar.(TexObjVar)
+	if !ok {
+		panic("progVar is not a TexObjVar")
+	}
+	obj.SetVar(pv)
+}
+
+// Var gets the program variables of the object.
+func (obj *TexObj) Var() TexObjVar {
+	return obj.progVar
+}
+
+// SetVar sets the program variables of the object and binds them to the
+// program. It's the typed version of SetProgVar().
+func (obj *TexObj) SetVar(progVar TexObjVar) {
+	obj.progVar = progVar
+
+	obj.BindProgVar(obj.progVar)
+	gl.UseProgram(obj.Program)
+	gl.Uniform1i(gl.GetUniformLocation(obj.Program, gl.Str("tex\x00")), 0)
+	obj.Uniform["envEnabled"] = gl.GetUniformLocation(obj.Program, gl.Str("envEnabled\x00"))
+	gl.BindFragDataLocation(obj.Program, 0, gl.Str("outputColor\x00"))
+}
+
+// SetVertices sets the vertices of x, y, z, u, v (e.g. NewUniTexCube()),
+// and computes the normals.
+func (obj *TexObj) SetVertices(vertices *[]float32) {
+	newVertices := AddNormalWithTexCoord(*vertices)
+	obj.SetVerticesWithNormalAndTexCoord(&newVertices)
+}
+
+// SetVerticesWithNormalAndTexCoord sets the vertices of
+// x, y, z, nx, ny, nz, u, v. (e.g. ObjMesh.VerticesWithNormalAndTexCoord())
+func (obj *TexObj) SetVerticesWithNormalAndTexCoord(vertices *[]float32) {
+	obj.Layout = PosNormalTexLayout
+	obj.BaseObj.SetVertices(vertices)
+}
+
+func (obj *TexObj) Render() {
+	gl.UseProgram(obj.Program)
+	obj.Binder.Upload(&obj.progVar)
+	obj.bindLighting(obj.progVar.Lights, obj.progVar.Ls, obj.progVar.Shadow, obj.progVar.Env)
+	if obj.progVar.Texture != nil {
+		obj.progVar.Texture.Bind(0)
+	}
+	obj.drawModel(obj.Uniform["model"])
+}
+
+// getTexObjVS returns the vertex shader of TexObj
+func getTexObjVS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+
+		layout(location = 0) in vec3 aPos;
+		layout(location = 1) in vec3 aNormal;
+		layout(location = 2) in vec2 aTexCoord;
+
+		out vec3 FragPos;
+		out vec3 Normal;
+		out vec2 TexCoord;
+
+		#include "sgl/transform.glsl"
+
+		void main() {
+			FragPos = vec3(model * vec4(aPos, 1.0));
+			Normal = mat3(transpose(inverse(model))) * aNormal;
+			TexCoord = aTexCoord;
+
+			gl_Position = projection * camera * vec4(FragPos, 1.0);
+		}
+		%v`,
+		"\x00",
+	)
+}
+
+// getTexObjFS returns the fragment shader of TexObj
+func getTexObjFS() string {
+	return fmt.Sprintf(
+		`
+		#version 330
+		out vec4 FragColor;
+
+		in vec3 Normal;
+		in vec3 FragPos;
+		in vec2 TexCoord;
+
+		uniform sampler2D tex;
+
+		#include "sgl/phong_lights.glsl"
+
+		void main() {
+			vec4 texColor = texture(tex, TexCoord);
+			FragColor = vec4(phongLights(Normal, FragPos, texColor.rgb), texColor.a);
+		}
+		%v`,
+		"\x00",
+	)
+}
@@ -0,0 +1,290 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"  // register the GIF decoder for NewTextureFromFile()
+	_ "image/jpeg" // register the JPEG decoder for NewTextureFromFile()
+	_ "image/png"  // register the PNG decoder for NewTextureFromFile()
+	"io"
+	"os"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// TextureFormat is the pixel format of a Texture.
+type TextureFormat int
+
+const (
+	// TextureRGBA keeps the red, green, blue and alpha channels.
+	TextureRGBA TextureFormat = iota
+
+	// TextureRGB keeps the red, green and blue channels.
+	TextureRGB
+
+	// TextureR keeps a single channel, which is the luminance of the image.
+	// It's usually used by the metallic, roughness and AO maps.
+	TextureR
+)
+
+// TextureOptions are the options of creating a Texture.
+// The zero value makes a linear RGBA texture with repeat wrapping,
+// linear filtering and no mipmaps.
+type TextureOptions struct {
+	// Format is the pixel format of the texture.
+	Format TextureFormat
+
+	// SRGB tells whether the colors of the image are in sRGB, like most
+	// PNG and JPEG files, so they're converted to linear space when they're
+	// sampled. It's not supported by TextureR.
+	SRGB bool
+
+	// WrapS and WrapT are the wrap modes of the texture coordinates,
+	// e.g. gl.REPEAT, gl.MIRRORED_REPEAT and gl.CLAMP_TO_EDGE.
+	// gl.REPEAT is used if they're 0.
+	WrapS int32
+	WrapT int32
+
+	// MinFilter and MagFilter are the filters of the texture,
+	// e.g. gl.NEAREST, gl.LINEAR and gl.LINEAR_MIPMAP_LINEAR.
+	// gl.LINEAR is used if they're 0, or gl.LINEAR_MIPMAP_LINEAR for
+	// MinFilter if Mipmaps is true.
+	MinFilter int32
+	MagFilter int32
+
+	// Mipmaps tells whether to generate the mipmaps of the texture.
+	Mipmaps bool
+
+	// FlipY flips the image vertically, since the first row of an image is
+	// the top but the first row of a GL texture is the bottom.
+	FlipY bool
+}
+
+// Texture is a 2D texture on the GPU.
+//
+// The textures which are not deleted by Delete() are deleted by Terminate().
+type Texture struct {
+	// ID is the name of the GL texture, which could be used by the raw GL
+	// functions.
+	ID uint32
+
+	// Width and Height are the size of the texture in pixels.
+	Width  int32
+	Height int32
+
+	// Format is the pixel format of the texture.
+	Format TextureFormat
+
+	// SRGB tells whether the texture is an sRGB texture.
+	SRGB bool
+}
+
+// textures are the textures that are not deleted yet.
+var textures = map[*Texture]bool{}
+
+// NewTextureFromFile decodes a PNG, JPEG or GIF file and uploads it as
+// a texture.
+func NewTextureFromFile(file string, opts TextureOptions) (*Texture, error) {
+	img, err := decodeImageFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewTexture(img, opts)
+}
+
+// decodeImageFile decodes an image file by image.Decode().
+func decodeImageFile(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", file, err)
+	}
+	return img, nil
+}
+
+// NewTextureFromReader decodes an image by image.Decode() and uploads it
+// as a texture. PNG, JPEG and GIF are supported by default, and other
+// formats could be registered by importing their decoders.
+func NewTextureFromReader(r io.Reader, opts TextureOptions) (*Texture, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewTexture(img, opts)
+}
+
+// NewTexture uploads an image as a texture.
+func NewTexture(img image.Image, opts TextureOptions) (*Texture, error) {
+	internalFormat, format, err := textureGLFormats(opts)
+	if err != nil {
+		return nil, err
+	}
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("the image is empty")
+	}
+	pix, width, height := texturePixels(img, opts.Format, opts.FlipY)
+
+	tex := &Texture{
+		Width:  int32(width),
+		Height: int32(height),
+		Format: opts.Format,
+		SRGB:   opts.SRGB,
+	}
+	gl.GenTextures(1, &tex.ID)
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	// the rows of RGB and R pixels are not 4-byte aligned
+	var alignment int32
+	gl.GetIntegerv(gl.UNPACK_ALIGNMENT, &alignment)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(
+		gl.TEXTURE_2D, 0, internalFormat, tex.Width, tex.Height, 0,
+		format, gl.UNSIGNED_BYTE, gl.Ptr(pix),
+	)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, alignment)
+
+	wrapS, wrapT := opts.WrapS, opts.WrapT
+	if wrapS == 0 {
+		wrapS = gl.REPEAT
+	}
+	if wrapT == 0 {
+		wrapT = gl.REPEAT
+	}
+	minFilter, magFilter := opts.MinFilter, opts.MagFilter
+	if minFilter == 0 {
+		minFilter = gl.LINEAR
+		if opts.Mipmaps {
+			minFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	}
+	if magFilter == 0 {
+		magFilter = gl.LINEAR
+	}
+	tex.SetWrap(wrapS, wrapT)
+	tex.SetFilter(minFilter, magFilter)
+	if opts.Mipmaps {
+		tex.GenerateMipmaps()
+	}
+
+	textures[tex] = true
+	return tex, nil
+}
+
+// textureGLFormats returns the internal format and the pixel format of the
+// texture.
+func textureGLFormats(opts TextureOptions) (int32, uint32, error) {
+	switch opts.Format {
+	case TextureRGBA:
+		if opts.SRGB {
+			return gl.SRGB8_ALPHA8, gl.RGBA, nil
+		}
+		return gl.RGBA8, gl.RGBA, nil
+	case TextureRGB:
+		if opts.SRGB {
+			return gl.SRGB8, gl.RGB, nil
+		}
+		return gl.RGB8, gl.RGB, nil
+	case TextureR:
+		if opts.SRGB {
+			return 0, 0, fmt.Errorf("sRGB is not supported by TextureR")
+		}
+		return gl.R8, gl.RED, nil
+	}
+	return 0, 0, fmt.Errorf("unknown texture format %v", opts.Format)
+}
+
+// texturePixels converts the image into the tightly packed pixels of the
+// format, whose first row is the top of the image unless flipY is true.
+func texturePixels(img image.Image, format TextureFormat, flipY bool) ([]byte, int, int) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	var src []byte
+	var channels int
+	if format == TextureR {
+		gray := image.NewGray(image.Rect(0, 0, width, height))
+		draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+		src, channels = gray.Pix, 1
+	} else {
+		// non-premultiplied alpha, like the pixels of the image files
+		nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+		src, channels = nrgba.Pix, 4
+	}
+
+	size := 4
+	if format == TextureRGB {
+		size = 3
+	} else if format == TextureR {
+		size = 1
+	}
+	if size == channels && !flipY {
+		return src, width, height
+	}
+
+	pix := make([]byte, width*height*size)
+	for y := 0; y < height; y++ {
+		srcY := y
+		if flipY {
+			srcY = height - 1 - y
+		}
+		for x := 0; x < width; x++ {
+			s := (srcY*width + x) * channels
+			copy(pix[(y*width+x)*size:(y*width+x+1)*size], src[s:s+size])
+		}
+	}
+	return pix, width, height
+}
+
+// Bind binds the texture to the texture unit, i.e. gl.TEXTURE0 + unit.
+// The sampler uniform variable in the shader should be set to the unit.
+func (tex *Texture) Bind(unit int32) {
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// SetWrap sets the wrap modes of the texture coordinates.
+func (tex *Texture) SetWrap(wrapS, wrapT int32) {
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrapS)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrapT)
+}
+
+// SetFilter sets the minifying and magnifying filters of the texture.
+// The mipmap filters of minFilter need the mipmaps (see GenerateMipmaps()).
+func (tex *Texture) SetFilter(minFilter, magFilter int32) {
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, magFilter)
+}
+
+// GenerateMipmaps generates the mipmaps of the texture.
+func (tex *Texture) GenerateMipmaps() {
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+}
+
+// Delete frees the texture.
+func (tex *Texture) Delete() {
+	if tex.ID != 0 {
+		gl.DeleteTextures(1, &tex.ID)
+	}
+	tex.ID = 0
+	delete(textures, tex)
+}
+
+// deleteTextures deletes the textures and the cube maps that are not
+// deleted yet.
+func deleteTextures() {
+	for tex := range textures {
+		tex.Delete()
+	}
+	for cm := range cubeMaps {
+		cm.Delete()
+	}
+}
@@ -0,0 +1,85 @@
+package sgl
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+func TestTextureGLFormats(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           TextureOptions
+		internalFormat int32
+		format         uint32
+	}{
+		{"rgba", TextureOptions{}, gl.RGBA8, gl.RGBA},
+		{"srgb rgba", TextureOptions{SRGB: true}, gl.SRGB8_ALPHA8, gl.RGBA},
+		{"rgb", TextureOptions{Format: TextureRGB}, gl.RGB8, gl.RGB},
+		{"srgb rgb", TextureOptions{Format: TextureRGB, SRGB: true}, gl.SRGB8, gl.RGB},
+		{"r", TextureOptions{Format: TextureR}, gl.R8, gl.RED},
+	}
+	for _, tt := range tests {
+		internalFormat, format, err := textureGLFormats(tt.opts)
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if internalFormat != tt.internalFormat || format != tt.format {
+			t.Errorf("%v: got %v and %v, want %v and %v", tt.name, internalFormat, format, tt.internalFormat, tt.format)
+		}
+	}
+
+	for _, opts := range []TextureOptions{{Format: TextureR, SRGB: true}, {Format: TextureFormat(10)}} {
+		if _, _, err := textureGLFormats(opts); err == nil {
+			t.Errorf("%+v: got nil error", opts)
+		}
+	}
+}
+
+func TestTexturePixels(t *testing.T) {
+	// a 2x2 image whose top row is red and green, and bottom row is blue
+	// and half transparent white
+	img := image.NewNRGBA(image.Rect(10, 20, 12, 22))
+	img.Set(10, 20, color.NRGBA{255, 0, 0, 255})
+	img.Set(11, 20, color.NRGBA{0, 255, 0, 255})
+	img.Set(10, 21, color.NRGBA{0, 0, 255, 255})
+	img.Set(11, 21, color.NRGBA{255, 255, 255, 128})
+
+	tests := []struct {
+		name   string
+		format TextureFormat
+		flipY  bool
+		want   []byte
+	}{
+		{"rgba", TextureRGBA, false, []byte{
+			255, 0, 0, 255, 0, 255, 0, 255,
+			0, 0, 255, 255, 255, 255, 255, 128,
+		}},
+		{"flipped rgba", TextureRGBA, true, []byte{
+			0, 0, 255, 255, 255, 255, 255, 128,
+			255, 0, 0, 255, 0, 255, 0, 255,
+		}},
+		{"rgb", TextureRGB, false, []byte{
+			255, 0, 0, 0, 255, 0,
+			0, 0, 255, 255, 255, 255,
+		}},
+		// the luminance, which is premultiplied by the alpha
+		{"flipped r", TextureR, true, []byte{
+			29, 128,
+			76, 150,
+		}},
+	}
+	for _, tt := range tests {
+		pix, width, height := texturePixels(img, tt.format, tt.flipY)
+		if width != 2 || height != 2 {
+			t.Errorf("%v: got size %vx%v, want 2x2", tt.name, width, height)
+		}
+		if !reflect.DeepEqual(pix, tt.want) {
+			t.Errorf("%v: got pixels %v, want %v", tt.name, pix, tt.want)
+		}
+	}
+}
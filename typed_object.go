@@ -0,0 +1,71 @@
+package sgl
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypedObject is an Object whose program variable struct is V, so passing a
+// wrong program variable struct to SetVar() is a compile error instead of a
+// panic of SetProgVar(). It's still an Object, so it could be added to a
+// Group or rendered with other objects.
+//
+// The built-in objects implement it, e.g. *SimpleObj is a
+// TypedObject[SimpleObjVar]. Use Typed() to get one from an Object.
+type TypedObject[V any] interface {
+	Object
+
+	// Var gets the program variables of the object.
+	Var() V
+
+	// SetVar sets the program variables of the object, like SetProgVar().
+	SetVar(progVar V)
+}
+
+// the built-in objects are typed objects
+var (
+	_ TypedObject[BaseObjVar]      = (*BaseObj)(nil)
+	_ TypedObject[SimpleObjVar]    = (*SimpleObj)(nil)
+	_ TypedObject[ColorObjVar]     = (*ColorObj)(nil)
+	_ TypedObject[PbrObjVar]       = (*PbrObj)(nil)
+	_ TypedObject[TexObjVar]       = (*TexObj)(nil)
+	_ TypedObject[SkyboxVar]       = (*Skybox)(nil)
+	_ TypedObject[NormalMapObjVar] = (*NormalMapObj)(nil)
+)
+
+// Typed returns obj as a TypedObject[V]. If obj doesn't implement
+// TypedObject[V] itself (e.g. a custom Object that only implements Object),
+// it's wrapped by the typed methods calling GetProgVar() and SetProgVar().
+// It panics if the program variable struct of obj is not V, so the mismatch
+// is caught where the object is created instead of where it's used. The
+// check is also made for the objects that implement TypedObject[V], since a
+// custom object that embeds BaseObj gets the Var() and SetVar() of
+// BaseObjVar even if its program variable struct is another one.
+//
+//	cube := sgl.Typed[sgl.SimpleObjVar](sgl.NewSimpleObj())
+//	cube.SetVar(sgl.SimpleObjVar{Vp: &vp, Ls: &ls, Mt: &mt})
+func Typed[V any](obj Object) TypedObject[V] {
+	if _, ok := obj.GetProgVar().(V); !ok {
+		panic(fmt.Sprintf(
+			"progVar of %T is %T, not %v",
+			obj, obj.GetProgVar(), reflect.TypeOf((*V)(nil)).Elem(),
+		))
+	}
+	if typed, ok := obj.(TypedObject[V]); ok {
+		return typed
+	}
+	return typedObject[V]{obj}
+}
+
+// typedObject adds the typed methods to an Object.
+type typedObject[V any] struct {
+	Object
+}
+
+func (obj typedObject[V]) Var() V {
+	return obj.GetProgVar().(V)
+}
+
+func (obj typedObject[V]) SetVar(progVar V) {
+	obj.SetProgVar(progVar)
+}
@@ -0,0 +1,71 @@
+package sgl
+
+import (
+	"testing"
+)
+
+type typedTestVar struct {
+	Value float32
+}
+
+// typedTestObj is a custom object that embeds BaseObj, so it gets the
+// Var() and SetVar() of BaseObjVar.
+type typedTestObj struct {
+	progVar typedTestVar
+	BaseObj
+}
+
+func (obj *typedTestObj) GetProgVar() interface{} {
+	return obj.progVar
+}
+
+func (obj *typedTestObj) SetProgVar(progVar interface{}) {
+	pv, ok := progVar.(typedTestVar)
+	if !ok {
+		panic("progVar is not a typedTestVar")
+	}
+	obj.progVar = pv
+}
+
+func TestTyped(t *testing.T) {
+	simple := &SimpleObj{}
+	if got := Typed[SimpleObjVar](simple); got != TypedObject[SimpleObjVar](simple) {
+		t.Errorf("got %T, want the SimpleObj itself", got)
+	}
+
+	custom := &typedTestObj{progVar: typedTestVar{Value: 1}}
+	typed := Typed[typedTestVar](custom)
+	if _, ok := typed.(typedObject[typedTestVar]); !ok {
+		t.Fatalf("got %T, want a wrapped object", typed)
+	}
+	if got := typed.Var(); got.Value != 1 {
+		t.Errorf("got var %v, want 1", got.Value)
+	}
+	typed.SetVar(typedTestVar{Value: 2})
+	if custom.progVar.Value != 2 {
+		t.Errorf("got var %v after SetVar, want 2", custom.progVar.Value)
+	}
+}
+
+func TestTypedMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed func()
+	}{
+		{"built-in", func() { Typed[ColorObjVar](&SimpleObj{}) }},
+		{"custom", func() { Typed[SimpleObjVar](&typedTestObj{}) }},
+		// typedTestObj implements TypedObject[BaseObjVar] by the embedded
+		// BaseObj, but its program variable struct is typedTestVar
+		{"promoted", func() { Typed[BaseObjVar](&typedTestObj{}) }},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: Typed() doesn't panic", tt.name)
+				}
+			}()
+			tt.typed()
+		}()
+	}
+}
@@ -0,0 +1,377 @@
+package sgl
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// UniformBinder binds the fields of a program variable struct to the uniform
+// variables of a program, and uploads them all by one Upload() call.
+//
+// The fields are bound by their `sgl` tags, e.g.
+//
+//	type MyObjVar struct {
+//		Color  mgl32.Vec3    `sgl:"color"`
+//		Weight [4]float32    `sgl:"weights"`
+//		Light  MyLight       `sgl:"light"` // binds light.pos, light.color ...
+//		Vp     *sgl.Viewpoint             // binds projection, camera, viewPos
+//	}
+//
+// The supported types are float32, int, int32, uint32, bool, mgl32 vectors
+// and matrices, and the arrays and slices of them except int and bool
+// (use int32 instead). The fields of a struct (or a pointer to a struct)
+// field are bound as "tag.field", and the fields of an array of structs are
+// bound as "tag[i].field". The fields of an untagged struct field are bound
+// as if they are the fields of the outer struct, which is how *Viewpoint,
+// *LightSrc and *Material are bound. The fields tagged `sgl:"-"` and the
+// unexported fields are ignored.
+//
+// The locations are resolved once by NewUniformBinder(), and the uniform
+// variables not used by the program are skipped. The samplers of the
+// BuiltinShaderIncludes are set to their texture units (e.g. ShadowMapUnit
+// and EnvMapUnit) by NewUniformBinder() too.
+//
+// If the program has the uniform block of an untagged *Viewpoint,
+// *LightSrc or *LightSet field (e.g. by including "sgl/viewpoint.glsl"), the field
+// updates the shared UniformBuffer of the block instead, which is only
+// uploaded when the data changes.
+type UniformBinder struct {
+	// Program is the program of the uniform variables.
+	Program uint32
+
+	typ      reflect.Type
+	uniforms []boundUniform
+	blocks   []boundBlock
+
+	// unused are the names of the uniform variables not used by the program
+	unused []string
+}
+
+// boundBlock is a shared uniform block bound to a field.
+type boundBlock struct {
+	path []uniformStep
+	typ  reflect.Type
+}
+
+// boundUniform is a uniform variable bound to a field.
+type boundUniform struct {
+	name     string
+	location int32
+	path     []uniformStep
+	set      uniformSetter
+	isArray  bool
+}
+
+// uniformStep is a step from a struct to a bound field. It's an index of a
+// field if field >= 0, otherwise it's the index of an array element.
+type uniformStep struct {
+	field int
+	elem  int
+}
+
+// uniformSetter uploads count elements from p to the location.
+type uniformSetter func(location int32, count int32, p unsafe.Pointer)
+
+// uniformSetters are the setters of the supported types.
+var uniformSetters = map[reflect.Type]uniformSetter{
+	reflect.TypeOf(float32(0)): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform1fv(l, n, (*float32)(p))
+	},
+	reflect.TypeOf(int32(0)): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform1iv(l, n, (*int32)(p))
+	},
+	reflect.TypeOf(uint32(0)): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform1uiv(l, n, (*uint32)(p))
+	},
+	reflect.TypeOf(mgl32.Vec2{}): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform2fv(l, n, (*float32)(p))
+	},
+	reflect.TypeOf(mgl32.Vec3{}): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform3fv(l, n, (*float32)(p))
+	},
+	reflect.TypeOf(mgl32.Vec4{}): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform4fv(l, n, (*float32)(p))
+	},
+	reflect.TypeOf(mgl32.Mat2{}): func(l int32, n int32, p unsafe.Pointer) {
+		gl.UniformMatrix2fv(l, n, false, (*float32)(p))
+	},
+	reflect.TypeOf(mgl32.Mat3{}): func(l int32, n int32, p unsafe.Pointer) {
+		gl.UniformMatrix3fv(l, n, false, (*float32)(p))
+	},
+	reflect.TypeOf(mgl32.Mat4{}): func(l int32, n int32, p unsafe.Pointer) {
+		gl.UniformMatrix4fv(l, n, false, (*float32)(p))
+	},
+}
+
+// scalarSetters are the setters of the types that can't be uploaded as
+// arrays, because their memory layouts are different from GLSL.
+var scalarSetters = map[reflect.Type]uniformSetter{
+	reflect.TypeOf(int(0)): func(l int32, n int32, p unsafe.Pointer) {
+		gl.Uniform1i(l, int32(*(*int)(p)))
+	},
+	reflect.TypeOf(false): func(l int32, n int32, p unsafe.Pointer) {
+		var b int32
+		if *(*bool)(p) {
+			b = 1
+		}
+		gl.Uniform1i(l, b)
+	},
+}
+
+// NewUniformBinder resolves the locations of the uniform variables bound to
+// the fields of progVar, which is a program variable struct or a pointer
+// to it. It returns an error if a tagged field has an unsupported type.
+func NewUniformBinder(program uint32, progVar interface{}) (*UniformBinder, error) {
+	t := reflect.TypeOf(progVar)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("progVar %T is not a struct", progVar)
+	}
+	b := &UniformBinder{Program: program, typ: t}
+	if err := b.bindStruct(t, "", nil, map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	for name, unit := range builtinSamplerUnits {
+		location := uniformLocation(program, name)
+		if location >= 0 {
+			gl.ProgramUniform1i(program, location, unit)
+		}
+	}
+	return b, nil
+}
+
+// builtinSamplerUnits are the texture units of the samplers declared by the
+// BuiltinShaderIncludes. The samplers of different types can't share a unit,
+// so they're set even if the textures are not used.
+var builtinSamplerUnits = map[string]int32{
+	"shadowMap": ShadowMapUnit,
+	"envMap":    EnvMapUnit,
+}
+
+// bindStruct binds the fields of the struct type t. prefix is prepended to
+// the names of the uniform variables, and path is the path to the struct.
+// visiting keeps the struct types being bound to stop recursive types.
+func (b *UniformBinder) bindStruct(
+	t reflect.Type, prefix string, path []uniformStep, visiting map[reflect.Type]bool,
+) error {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag, tagged := f.Tag.Lookup("sgl")
+		if tag == "-" {
+			continue
+		}
+		fieldPath := append(append([]uniformStep{}, path...), uniformStep{field: i})
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if !tagged || tag == "" {
+			if b.bindBlock(ft, fieldPath) {
+				continue
+			}
+			// untagged struct fields are flattened, others are ignored
+			if ft.Kind() == reflect.Struct {
+				if err := b.bindStruct(ft, prefix, fieldPath, visiting); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := b.bindField(ft, prefix+tag, fieldPath, visiting); err != nil {
+			return fmt.Errorf("field %v: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// bindBlock binds a field to the shared uniform block of type t, and
+// returns false if t is not a shared block or the program doesn't have it.
+func (b *UniformBinder) bindBlock(t reflect.Type, path []uniformStep) bool {
+	block, ok := sharedUniformBlocks[t]
+	if !ok {
+		return false
+	}
+	index := gl.GetUniformBlockIndex(b.Program, gl.Str(block.name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return false
+	}
+	gl.UniformBlockBinding(b.Program, index, block.binding)
+	b.blocks = append(b.blocks, boundBlock{path: path, typ: t})
+	return true
+}
+
+// bindField binds a tagged field of type t to the uniform variable name.
+func (b *UniformBinder) bindField(
+	t reflect.Type, name string, path []uniformStep, visiting map[reflect.Type]bool,
+) error {
+	t = basicUniformType(t)
+	if set, ok := uniformSetters[t]; ok {
+		b.bind(name, path, set, false)
+		return nil
+	}
+	if set, ok := scalarSetters[t]; ok {
+		b.bind(name, path, set, false)
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return b.bindStruct(t, name+".", path, visiting)
+	case reflect.Array, reflect.Slice:
+		if set, ok := uniformSetters[basicUniformType(t.Elem())]; ok {
+			b.bind(name, path, set, true)
+			return nil
+		}
+		et := t.Elem()
+		for et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		if t.Kind() == reflect.Array && et.Kind() == reflect.Struct {
+			for i := 0; i < t.Len(); i++ {
+				elemPath := append(append([]uniformStep{}, path...), uniformStep{field: -1, elem: i})
+				err := b.bindStruct(et, fmt.Sprintf("%v[%v].", name, i), elemPath, visiting)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported uniform type %v", t)
+}
+
+// basicUniformType returns the unnamed type of a named basic type (e.g.
+// LightType), so it's uploaded like the basic type.
+func basicUniformType(t reflect.Type) reflect.Type {
+	switch t.Kind() {
+	case reflect.Float32:
+		return reflect.TypeOf(float32(0))
+	case reflect.Int32:
+		return reflect.TypeOf(int32(0))
+	case reflect.Uint32:
+		return reflect.TypeOf(uint32(0))
+	case reflect.Int:
+		return reflect.TypeOf(int(0))
+	case reflect.Bool:
+		return reflect.TypeOf(false)
+	}
+	return t
+}
+
+// uniformLocation returns the location of a uniform variable of a program.
+// It's replaced by the tests, which run without an OpenGL context.
+var uniformLocation = func(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+
+// bind resolves the location of a uniform variable, and keeps it if
+// the variable is used by the program.
+func (b *UniformBinder) bind(name string, path []uniformStep, set uniformSetter, isArray bool) {
+	location := uniformLocation(b.Program, name)
+	if location < 0 {
+		b.unused = append(b.unused, name)
+		return
+	}
+	b.uniforms = append(b.uniforms, boundUniform{
+		name:     name,
+		location: location,
+		path:     path,
+		set:      set,
+		isArray:  isArray,
+	})
+}
+
+// Locations returns the locations of the bound uniform variables, keyed by
+// their names. The variables not used by the program are at -1 like
+// gl.GetUniformLocation() returns, so setting them does nothing instead of
+// setting the variable at location 0.
+func (b *UniformBinder) Locations() map[string]int32 {
+	locations := map[string]int32{}
+	for _, name := range b.unused {
+		locations[name] = -1
+	}
+	for _, u := range b.uniforms {
+		locations[u.name] = u.location
+	}
+	return locations
+}
+
+// Upload uploads the fields of progVar to the uniform variables.
+// progVar should be the same type as the one given to NewUniformBinder(),
+// and passing a pointer saves a copy of the struct. The program should be
+// in use. The fields behind nil pointers and the empty slices are skipped.
+func (b *UniformBinder) Upload(progVar interface{}) {
+	v := reflect.ValueOf(progVar)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	} else if v.IsValid() {
+		// make it addressable to get the pointers of the fields
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+	if !v.IsValid() || v.Type() != b.typ {
+		panic(fmt.Sprintf("progVar %T is not a %v", progVar, b.typ))
+	}
+
+	for _, blk := range b.blocks {
+		fv, ok := valueByPath(v, blk.path)
+		if !ok {
+			continue
+		}
+		sharedUniformBuffer(blk.typ).Update(fv.Addr().Interface())
+	}
+	for _, u := range b.uniforms {
+		fv, ok := valueByPath(v, u.path)
+		if !ok {
+			continue
+		}
+		if !u.isArray {
+			u.set(u.location, 1, unsafe.Pointer(fv.UnsafeAddr()))
+			continue
+		}
+		if fv.Len() == 0 {
+			continue
+		}
+		u.set(u.location, int32(fv.Len()), unsafe.Pointer(fv.Index(0).UnsafeAddr()))
+	}
+}
+
+// valueByPath returns the field of v at path. It returns false if the field
+// is behind a nil pointer.
+func valueByPath(v reflect.Value, path []uniformStep) (reflect.Value, bool) {
+	for _, s := range path {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		if s.field >= 0 {
+			v = v.Field(s.field)
+		} else {
+			v = v.Index(s.elem)
+		}
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
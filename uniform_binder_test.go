@@ -0,0 +1,168 @@
+package sgl
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type binderTestLight struct {
+	Pos   mgl32.Vec3 `sgl:"pos"`
+	Color mgl32.Vec3 `sgl:"color"`
+}
+
+type binderTestCommon struct {
+	Time float32 `sgl:"time"`
+}
+
+type binderTestVar struct {
+	Color   mgl32.Vec3          `sgl:"color"`
+	Weights [4]float32          `sgl:"weights"`
+	Offsets []mgl32.Vec2        `sgl:"offsets"`
+	Count   int                 `sgl:"count"`
+	Enabled bool                `sgl:"enabled"`
+	Model   mgl32.Mat4          `sgl:"model"`
+	Light   *binderTestLight    `sgl:"light"`
+	Lights  [2]binderTestLight  `sgl:"lights"`
+	Common  *binderTestCommon   // flattened
+	Ignored float32             `sgl:"-"`
+	Untag   float32             // ignored
+	private float32             // ignored
+	Unused  mgl32.Vec4          `sgl:"unused"`
+	Nested  binderTestRecursive `sgl:"nested"`
+}
+
+type binderTestRecursive struct {
+	Value float32              `sgl:"value"`
+	Next  *binderTestRecursive `sgl:"next"`
+}
+
+// fakeUniformLocations makes uniformLocation return the index of the name
+// in used, or -1 if the name is not used.
+func fakeUniformLocations(t *testing.T, used ...string) {
+	orig := uniformLocation
+	t.Cleanup(func() { uniformLocation = orig })
+	uniformLocation = func(program uint32, name string) int32 {
+		for i, u := range used {
+			if u == name {
+				return int32(i)
+			}
+		}
+		return -1
+	}
+}
+
+func TestUniformBinderLocations(t *testing.T) {
+	used := []string{
+		"color", "weights", "offsets", "count", "enabled", "model",
+		"light.pos", "light.color", "lights[0].pos", "lights[0].color",
+		"lights[1].pos", "lights[1].color", "time", "nested.value",
+	}
+	fakeUniformLocations(t, used...)
+	b, err := NewUniformBinder(1, &binderTestVar{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int32{"unused": -1}
+	for i, name := range used {
+		want[name] = int32(i)
+	}
+	if got := b.Locations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got locations %v, want %v", got, want)
+	}
+}
+
+func TestNewUniformBinderError(t *testing.T) {
+	fakeUniformLocations(t)
+	tests := []struct {
+		name    string
+		progVar interface{}
+	}{
+		{"nil", nil},
+		{"not a struct", 1.0},
+		{"pointer to not a struct", new(float32)},
+		{"map", struct {
+			M map[string]float32 `sgl:"m"`
+		}{}},
+		{"int slice", struct {
+			S []int `sgl:"s"`
+		}{}},
+		{"bool array", struct {
+			A [2]bool `sgl:"a"`
+		}{}},
+		{"float64", struct {
+			F float64 `sgl:"f"`
+		}{}},
+	}
+	for _, tt := range tests {
+		if _, err := NewUniformBinder(1, tt.progVar); err == nil {
+			t.Errorf("%v: got nil error", tt.name)
+		}
+	}
+}
+
+func TestUniformBinderUpload(t *testing.T) {
+	fakeUniformLocations(t, "color", "offsets", "light.pos", "lights[1].color", "time", "count")
+	b, err := NewUniformBinder(1, binderTestVar{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// record the uploaded values instead of calling OpenGL
+	got := map[string][]float32{}
+	for i := range b.uniforms {
+		u := &b.uniforms[i]
+		name := u.name
+		u.set = func(location int32, count int32, p unsafe.Pointer) {
+			if name == "count" {
+				got[name] = []float32{float32(*(*int)(p))}
+				return
+			}
+			size := map[string]int32{"color": 3, "offsets": 2, "light.pos": 3, "lights[1].color": 3, "time": 1}[name]
+			got[name] = append([]float32{}, unsafe.Slice((*float32)(p), count*size)...)
+		}
+	}
+
+	progVar := binderTestVar{
+		Color:   mgl32.Vec3{1, 2, 3},
+		Offsets: []mgl32.Vec2{{1, 2}, {3, 4}},
+		Count:   5,
+		Lights:  [2]binderTestLight{{}, {Color: mgl32.Vec3{7, 8, 9}}},
+	}
+	b.Upload(&progVar)
+	want := map[string][]float32{
+		"color":           {1, 2, 3},
+		"offsets":         {1, 2, 3, 4},
+		"lights[1].color": {7, 8, 9},
+		"count":           {5},
+		// light and time are behind nil pointers
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got uploads %v, want %v", got, want)
+	}
+
+	// by value, with an empty slice and the pointers set
+	got = map[string][]float32{}
+	progVar.Offsets = nil
+	progVar.Light = &binderTestLight{Pos: mgl32.Vec3{4, 5, 6}}
+	progVar.Common = &binderTestCommon{Time: 0.5}
+	b.Upload(progVar)
+	want = map[string][]float32{
+		"color":           {1, 2, 3},
+		"light.pos":       {4, 5, 6},
+		"lights[1].color": {7, 8, 9},
+		"time":            {0.5},
+		"count":           {5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got uploads %v, want %v", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("uploading a different type doesn't panic")
+		}
+	}()
+	b.Upload(&binderTestLight{})
+}
@@ -0,0 +1,410 @@
+package sgl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The binding points of the uniform blocks shared by all programs.
+const (
+	// ViewpointBinding is the binding point of the "Viewpoint" block
+	// (see BuiltinShaderIncludes["sgl/viewpoint.glsl"]).
+	ViewpointBinding uint32 = 0
+
+	// LightSrcBinding is the binding point of the "LightSrc" block
+	// (see BuiltinShaderIncludes["sgl/light_src.glsl"]).
+	LightSrcBinding uint32 = 1
+
+	// LightSetBinding is the binding point of the "LightSet" block
+	// (see BuiltinShaderIncludes["sgl/light_set.glsl"]).
+	LightSetBinding uint32 = 2
+)
+
+// UniformBuffer is a uniform buffer object that keeps a std140 uniform block,
+// which could be shared by many programs and updated once for all of them.
+//
+// The block is described by a struct whose `sgl` tagged fields are packed
+// in order, so the order of the fields should be the same as the members of
+// the block. The supported types are float32, int, int32, uint32, bool,
+// mgl32 vectors and matrices, structs and the arrays of them. Like
+// UniformBinder, the tagged fields of the untagged struct fields are packed
+// as if they are the fields of the outer struct.
+//
+//	type Fog struct {
+//		Color   mgl32.Vec3 `sgl:"fogColor"`
+//		Density float32    `sgl:"fogDensity"`
+//	}
+//
+//	layout(std140) uniform Fog {
+//		vec3 fogColor;
+//		float fogDensity;
+//	};
+type UniformBuffer struct {
+	// Name is the name of the uniform block in the shaders.
+	Name string
+
+	// Binding is the binding point of the buffer.
+	Binding uint32
+
+	// Buffer is the uniform buffer object.
+	Buffer uint32
+
+	typ    reflect.Type
+	fields []std140Field
+	data   []byte
+	packed []byte
+
+	// last is a copy of the memory of the last packed block, which skips
+	// packing the same block again. It's only used if flat is true, since
+	// the targets of the pointers could change without changing the block.
+	last []byte
+	flat bool
+}
+
+// std140Field is a field packed at offset. count is the length of the
+// field if it's an array of a basic type, otherwise it's 0.
+type std140Field struct {
+	path   []uniformStep
+	offset int
+	leaf   std140Type
+	count  int
+	stride int
+}
+
+// std140Type is the std140 layout of a basic type. The matrices are packed
+// as arrays of cols columns, and each column takes colSize bytes.
+type std140Type struct {
+	typ     reflect.Type
+	size    int
+	align   int
+	cols    int
+	colSize int
+}
+
+// std140Types are the std140 layouts of the basic types.
+var std140Types = map[reflect.Type]std140Type{
+	reflect.TypeOf(float32(0)):   {size: 4, align: 4},
+	reflect.TypeOf(int32(0)):     {size: 4, align: 4},
+	reflect.TypeOf(uint32(0)):    {size: 4, align: 4},
+	reflect.TypeOf(int(0)):       {size: 4, align: 4},
+	reflect.TypeOf(false):        {size: 4, align: 4},
+	reflect.TypeOf(mgl32.Vec2{}): {size: 8, align: 8},
+	reflect.TypeOf(mgl32.Vec3{}): {size: 12, align: 16},
+	reflect.TypeOf(mgl32.Vec4{}): {size: 16, align: 16},
+	reflect.TypeOf(mgl32.Mat2{}): {size: 32, align: 16, cols: 2, colSize: 8},
+	reflect.TypeOf(mgl32.Mat3{}): {size: 48, align: 16, cols: 3, colSize: 12},
+	reflect.TypeOf(mgl32.Mat4{}): {size: 64, align: 16, cols: 4, colSize: 16},
+}
+
+// NewUniformBuffer creates a uniform buffer for the block described by
+// block, which is a struct or a pointer to it, and binds the buffer to the
+// binding point. It returns an error if a tagged field has an unsupported
+// type. Call Bind() to use the buffer in a program.
+func NewUniformBuffer(name string, binding uint32, block interface{}) (*UniformBuffer, error) {
+	t := reflect.TypeOf(block)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("block %T is not a struct", block)
+	}
+	b, err := newUniformBufferLayout(name, binding, t)
+	if err != nil {
+		return nil, err
+	}
+
+	gl.GenBuffers(1, &b.Buffer)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, b.Buffer)
+	gl.BufferData(gl.UNIFORM_BUFFER, len(b.data), gl.Ptr(b.data), gl.DYNAMIC_DRAW)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, b.Buffer)
+	b.Update(block)
+	return b, nil
+}
+
+// newUniformBufferLayout lays out the block of the struct type t without
+// creating the buffer object.
+func newUniformBufferLayout(name string, binding uint32, t reflect.Type) (*UniformBuffer, error) {
+	b := &UniformBuffer{Name: name, Binding: binding, typ: t}
+	size, err := b.layoutStruct(t, nil, 0, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	size = alignStd140(size, 16)
+	b.data = make([]byte, size)
+	b.packed = make([]byte, size)
+	b.flat = isFlatStd140Type(t)
+	return b, nil
+}
+
+// layoutStruct lays out the fields of the struct type t from offset,
+// and returns the end of the struct.
+func (b *UniformBuffer) layoutStruct(
+	t reflect.Type, path []uniformStep, offset int, visiting map[reflect.Type]bool,
+) (int, error) {
+	if visiting[t] {
+		return offset, fmt.Errorf("recursive uniform block type %v", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag, tagged := f.Tag.Lookup("sgl")
+		if tag == "-" {
+			continue
+		}
+		fieldPath := append(append([]uniformStep{}, path...), uniformStep{field: i})
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		var err error
+		if !tagged || tag == "" {
+			// untagged struct fields are flattened, others are ignored
+			if ft.Kind() == reflect.Struct {
+				offset, err = b.layoutStruct(ft, fieldPath, offset, visiting)
+			}
+		} else {
+			offset, err = b.layoutField(ft, fieldPath, offset, visiting)
+		}
+		if err != nil {
+			return offset, fmt.Errorf("field %v: %w", f.Name, err)
+		}
+	}
+	return offset, nil
+}
+
+// layoutField lays out a tagged field of type t from offset,
+// and returns the end of the field.
+func (b *UniformBuffer) layoutField(
+	t reflect.Type, path []uniformStep, offset int, visiting map[reflect.Type]bool,
+) (int, error) {
+	t = basicUniformType(t)
+	if leaf, ok := std140Types[t]; ok {
+		leaf.typ = t
+		offset = alignStd140(offset, leaf.align)
+		b.fields = append(b.fields, std140Field{path: path, offset: offset, leaf: leaf})
+		return offset + leaf.size, nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		// structs are aligned to vec4, and so are the members after them
+		end, err := b.layoutStruct(t, path, alignStd140(offset, 16), visiting)
+		return alignStd140(end, 16), err
+	case reflect.Array:
+		offset = alignStd140(offset, 16)
+		et := basicUniformType(t.Elem())
+		if leaf, ok := std140Types[et]; ok {
+			leaf.typ = et
+			stride := alignStd140(leaf.size, 16)
+			b.fields = append(b.fields, std140Field{
+				path:   path,
+				offset: offset,
+				leaf:   leaf,
+				count:  t.Len(),
+				stride: stride,
+			})
+			return offset + stride*t.Len(), nil
+		}
+		for et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Struct {
+			for i := 0; i < t.Len(); i++ {
+				elemPath := append(append([]uniformStep{}, path...), uniformStep{field: -1, elem: i})
+				end, err := b.layoutStruct(et, elemPath, offset, visiting)
+				if err != nil {
+					return end, err
+				}
+				offset = alignStd140(end, 16)
+			}
+			return offset, nil
+		}
+	}
+	return offset, fmt.Errorf("unsupported uniform block type %v", t)
+}
+
+// alignStd140 rounds offset up to a multiple of align.
+func alignStd140(offset int, align int) int {
+	return (offset + align - 1) / align * align
+}
+
+// Update packs block into the buffer. block should be the same type as the
+// one given to NewUniformBuffer(), and passing a pointer saves a copy of the
+// struct. The buffer is only uploaded when the packed data changes, and a
+// block without pointers is only packed when it changes, so it's cheap to
+// update it with the same data many times in a frame. The fields behind nil
+// pointers are packed as zeros.
+func (b *UniformBuffer) Update(block interface{}) {
+	if !b.pack(block) {
+		return
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, b.Buffer)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(b.data), gl.Ptr(b.data))
+}
+
+// pack packs block into b.data, and returns false if b.data doesn't change.
+func (b *UniformBuffer) pack(block interface{}) bool {
+	v := reflect.ValueOf(block)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	} else if v.IsValid() {
+		// make it addressable to get the pointers of the fields
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+	if !v.IsValid() || v.Type() != b.typ {
+		panic(fmt.Sprintf("block %T is not a %v", block, b.typ))
+	}
+
+	if b.flat {
+		mem := unsafe.Slice((*byte)(unsafe.Pointer(v.UnsafeAddr())), b.typ.Size())
+		if b.last != nil && bytes.Equal(mem, b.last) {
+			return false
+		}
+		b.last = append(b.last[:0], mem...)
+	}
+
+	for i := range b.packed {
+		b.packed[i] = 0
+	}
+	for _, f := range b.fields {
+		fv, ok := valueByPath(v, f.path)
+		if !ok {
+			continue
+		}
+		if f.count == 0 {
+			packStd140(b.packed[f.offset:], f.leaf, unsafe.Pointer(fv.UnsafeAddr()))
+			continue
+		}
+		for i := 0; i < f.count; i++ {
+			p := unsafe.Pointer(fv.Index(i).UnsafeAddr())
+			packStd140(b.packed[f.offset+i*f.stride:], f.leaf, p)
+		}
+	}
+	if bytes.Equal(b.packed, b.data) {
+		return false
+	}
+	copy(b.data, b.packed)
+	return true
+}
+
+// isFlatStd140Type returns true if the memory of t holds all its values,
+// i.e. t has no pointers, slices or maps.
+func isFlatStd140Type(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int32, reflect.Uint32, reflect.Float32:
+		return true
+	case reflect.Array:
+		return isFlatStd140Type(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isFlatStd140Type(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// packStd140 packs the value of the basic type at p into dst.
+func packStd140(dst []byte, leaf std140Type, p unsafe.Pointer) {
+	switch leaf.typ.Kind() {
+	case reflect.Int:
+		*(*int32)(unsafe.Pointer(&dst[0])) = int32(*(*int)(p))
+	case reflect.Bool:
+		var i int32
+		if *(*bool)(p) {
+			i = 1
+		}
+		*(*int32)(unsafe.Pointer(&dst[0])) = i
+	default:
+		if leaf.cols == 0 {
+			copy(dst, unsafe.Slice((*byte)(p), leaf.size))
+			return
+		}
+		// the columns of the matrices are aligned to vec4
+		src := unsafe.Slice((*byte)(p), leaf.cols*leaf.colSize)
+		for c := 0; c < leaf.cols; c++ {
+			copy(dst[c*16:], src[c*leaf.colSize:(c+1)*leaf.colSize])
+		}
+	}
+}
+
+// Bind makes the uniform block of the program use the buffer.
+// It returns false if the program doesn't have the block.
+func (b *UniformBuffer) Bind(program uint32) bool {
+	index := gl.GetUniformBlockIndex(program, gl.Str(b.Name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return false
+	}
+	gl.UniformBlockBinding(program, index, b.Binding)
+	return true
+}
+
+// Delete frees the buffer.
+func (b *UniformBuffer) Delete() {
+	if b.Buffer != 0 {
+		gl.DeleteBuffers(1, &b.Buffer)
+	}
+	b.Buffer = 0
+}
+
+// sharedUniformBlock is a uniform block shared by all programs.
+type sharedUniformBlock struct {
+	name    string
+	binding uint32
+}
+
+// sharedUniformBlocks are the uniform blocks shared by all programs. If the
+// program of an object has the block, UniformBinder updates the shared
+// buffer instead of uploading the uniform variables of the struct, so the
+// Viewpoint and the LightSrc shared by many objects are uploaded once per
+// frame.
+var sharedUniformBlocks = map[reflect.Type]sharedUniformBlock{
+	reflect.TypeOf(Viewpoint{}): {name: "Viewpoint", binding: ViewpointBinding},
+	reflect.TypeOf(LightSrc{}):  {name: "LightSrc", binding: LightSrcBinding},
+	reflect.TypeOf(LightSet{}):  {name: "LightSet", binding: LightSetBinding},
+}
+
+// sharedUniformBuffers are the buffers of sharedUniformBlocks, which are
+// created when they're used for the first time.
+var sharedUniformBuffers = map[reflect.Type]*UniformBuffer{}
+
+// sharedUniformBuffer returns the buffer of the shared uniform block of t.
+func sharedUniformBuffer(t reflect.Type) *UniformBuffer {
+	if b, ok := sharedUniformBuffers[t]; ok {
+		return b
+	}
+	block := sharedUniformBlocks[t]
+	b, err := NewUniformBuffer(block.name, block.binding, reflect.New(t).Interface())
+	if err != nil {
+		panic(err)
+	}
+	sharedUniformBuffers[t] = b
+	return b
+}
+
+// updateSharedUniformBuffer updates the shared buffer of the uniform block
+// of block, which is a pointer to a type of sharedUniformBlocks.
+func updateSharedUniformBuffer(block interface{}) {
+	sharedUniformBuffer(reflect.TypeOf(block).Elem()).Update(block)
+}
+
+// deleteSharedUniformBuffers deletes the buffers of sharedUniformBlocks.
+func deleteSharedUniformBuffers() {
+	for t, b := range sharedUniformBuffers {
+		b.Delete()
+		delete(sharedUniformBuffers, t)
+	}
+}
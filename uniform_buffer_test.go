@@ -0,0 +1,207 @@
+package sgl
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type std140TestLight struct {
+	Pos       mgl32.Vec3 `sgl:"pos"`
+	Intensity float32    `sgl:"intensity"`
+}
+
+type std140TestElem struct {
+	Value float32 `sgl:"value"`
+}
+
+type std140TestBlock struct {
+	A float32           `sgl:"a"`
+	B mgl32.Vec3        `sgl:"b"`
+	C float32           `sgl:"c"`
+	D mgl32.Vec2        `sgl:"d"`
+	E mgl32.Mat3        `sgl:"e"`
+	F [2]float32        `sgl:"f"`
+	G std140TestLight   `sgl:"g"`
+	H int               `sgl:"h"`
+	I bool              `sgl:"i"`
+	J [2]std140TestElem `sgl:"j"`
+	K float32           `sgl:"-"`
+	L float32           // ignored
+}
+
+type std140TestPointer struct {
+	A     float32          `sgl:"a"`
+	Light *std140TestLight `sgl:"light"`
+}
+
+// std140Floats reads the float32 values at the offsets of data.
+func std140Floats(data []byte, offsets ...int) []float32 {
+	values := []float32{}
+	for _, offset := range offsets {
+		values = append(values, math.Float32frombits(binary.LittleEndian.Uint32(data[offset:])))
+	}
+	return values
+}
+
+func TestUniformBufferLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		block interface{}
+		size  int
+		flat  bool
+	}{
+		{"block", std140TestBlock{}, 192, true},
+		{"pointer", std140TestPointer{}, 32, false},
+		{"viewpoint", Viewpoint{}, 144, true},
+		{"light source", LightSrc{}, 32, true},
+	}
+	for _, tt := range tests {
+		b, err := newUniformBufferLayout("Block", 0, reflect.TypeOf(tt.block))
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if len(b.data) != tt.size || b.flat != tt.flat {
+			t.Errorf("%v: got size %v and flat %v, want %v and %v", tt.name, len(b.data), b.flat, tt.size, tt.flat)
+		}
+	}
+}
+
+func TestUniformBufferLayoutError(t *testing.T) {
+	type recursive struct {
+		Value float32    `sgl:"value"`
+		Next  *recursive `sgl:"next"`
+	}
+	tests := []struct {
+		name  string
+		block interface{}
+	}{
+		{"float64", struct {
+			F float64 `sgl:"f"`
+		}{}},
+		{"slice", struct {
+			S []float32 `sgl:"s"`
+		}{}},
+		{"map", struct {
+			M map[string]float32 `sgl:"m"`
+		}{}},
+		{"recursive", recursive{}},
+	}
+	for _, tt := range tests {
+		if _, err := newUniformBufferLayout("Block", 0, reflect.TypeOf(tt.block)); err == nil {
+			t.Errorf("%v: got nil error", tt.name)
+		}
+	}
+}
+
+func TestUniformBufferPack(t *testing.T) {
+	b, err := newUniformBufferLayout("Block", 0, reflect.TypeOf(std140TestBlock{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := std140TestBlock{
+		A: 1,
+		B: mgl32.Vec3{2, 3, 4},
+		C: 5,
+		D: mgl32.Vec2{6, 7},
+		E: mgl32.Mat3{8, 9, 10, 11, 12, 13, 14, 15, 16},
+		F: [2]float32{17, 18},
+		G: std140TestLight{Pos: mgl32.Vec3{19, 20, 21}, Intensity: 22},
+		H: 23,
+		I: true,
+		J: [2]std140TestElem{{24}, {25}},
+		K: 26,
+		L: 27,
+	}
+	if !b.pack(&block) {
+		t.Fatal("packing a new block returns false")
+	}
+	got := std140Floats(b.data,
+		0,          // a
+		16, 20, 24, // b
+		28,     // c, after the vec3
+		32, 36, // d
+		48, 52, 56, 64, 68, 72, 80, 84, 88, // e, the columns are aligned to vec4
+		96, 112, // f, the elements are aligned to vec4
+		128, 132, 136, 140, // g
+		160, 176, // j, the structs are aligned to vec4
+	)
+	want := []float32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 24, 25}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got packed values %v, want %v", got, want)
+	}
+	if h, i := int32(binary.LittleEndian.Uint32(b.data[144:])), binary.LittleEndian.Uint32(b.data[148:]); h != 23 || i != 1 {
+		t.Errorf("got h %v and i %v, want 23 and 1", h, i)
+	}
+
+	// the same block, by value or by pointer
+	if b.pack(block) || b.pack(&block) {
+		t.Error("packing the same block returns true")
+	}
+	// the ignored fields don't change the packed data
+	block.K = 0
+	if b.pack(&block) {
+		t.Error("packing the block with an ignored field changed returns true")
+	}
+	block.G.Intensity = 0.5
+	if !b.pack(&block) || std140Floats(b.data, 140)[0] != 0.5 {
+		t.Error("the changed block isn't packed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("packing a different type doesn't panic")
+		}
+	}()
+	b.pack(&std140TestLight{})
+}
+
+func TestUniformBufferPackPointer(t *testing.T) {
+	b, err := newUniformBufferLayout("Block", 0, reflect.TypeOf(std140TestPointer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	light := &std140TestLight{Pos: mgl32.Vec3{1, 2, 3}, Intensity: 4}
+	block := std140TestPointer{A: 5, Light: light}
+	if !b.pack(&block) {
+		t.Fatal("packing a new block returns false")
+	}
+	if got := std140Floats(b.data, 0, 16, 20, 24, 28); !reflect.DeepEqual(got, []float32{5, 1, 2, 3, 4}) {
+		t.Errorf("got packed values %v", got)
+	}
+	// the target of the pointer changes without changing the block
+	light.Intensity = 6
+	if !b.pack(&block) || std140Floats(b.data, 28)[0] != 6 {
+		t.Error("the changed light isn't packed")
+	}
+	// the fields behind nil pointers are packed as zeros
+	block.Light = nil
+	if !b.pack(&block) {
+		t.Error("the block without light isn't packed")
+	}
+	if got := std140Floats(b.data, 0, 16, 20, 24, 28); !reflect.DeepEqual(got, []float32{5, 0, 0, 0, 0}) {
+		t.Errorf("got packed values %v", got)
+	}
+}
+
+func TestAlignStd140(t *testing.T) {
+	tests := []struct {
+		offset, align, want int
+	}{
+		{0, 16, 0},
+		{1, 16, 16},
+		{16, 16, 16},
+		{28, 4, 28},
+		{28, 8, 32},
+		{12, 16, 16},
+	}
+	for _, tt := range tests {
+		if got := alignStd140(tt.offset, tt.align); got != tt.want {
+			t.Errorf("alignStd140(%v, %v) = %v, want %v", tt.offset, tt.align, got, tt.want)
+		}
+	}
+}
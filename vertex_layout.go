@@ -0,0 +1,129 @@
+package sgl
+
+import (
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// VertexAttrib describes an attribute (e.g. position, normal, color or
+// texture coordinate) of the vertices in a vertex array.
+type VertexAttrib struct {
+	// Name is the name of the input variable in the vertex shader.
+	Name string
+
+	// Location is the location of the input variable in the vertex shader,
+	// i.e. "layout (location = 0) in vec3 aPos;". If it's negative, the
+	// location is looked up by Name.
+	Location int32
+
+	// Size is the number of components of the attribute, e.g. 3 for vec3.
+	Size int32
+
+	// Type is the data type of the components. gl.FLOAT is used if it's 0.
+	Type uint32
+
+	// Normalized tells whether the integer values should be mapped to
+	// [0, 1] (unsigned) or [-1, 1] (signed) when they're converted to floats.
+	Normalized bool
+}
+
+// VertexLayout describes how the attributes are interleaved in a vertex
+// array. The attributes are tightly packed in the order of the layout.
+type VertexLayout []VertexAttrib
+
+var (
+	// PosLayout is the layout of x, y, z.
+	PosLayout = VertexLayout{
+		{Name: "aPos", Location: 0, Size: 3},
+	}
+
+	// PosNormalLayout is the layout of x, y, z, nx, ny, nz.
+	PosNormalLayout = VertexLayout{
+		{Name: "aPos", Location: 0, Size: 3},
+		{Name: "aNormal", Location: 1, Size: 3},
+	}
+
+	// PosNormalColorLayout is the layout of x, y, z, nx, ny, nz, r, g, b.
+	PosNormalColorLayout = VertexLayout{
+		{Name: "aPos", Location: 0, Size: 3},
+		{Name: "aNormal", Location: 1, Size: 3},
+		{Name: "aColor", Location: 2, Size: 3},
+	}
+
+	// PosNormalTexLayout is the layout of x, y, z, nx, ny, nz, u, v.
+	PosNormalTexLayout = VertexLayout{
+		{Name: "aPos", Location: 0, Size: 3},
+		{Name: "aNormal", Location: 1, Size: 3},
+		{Name: "aTexCoord", Location: 2, Size: 2},
+	}
+
+	// PosNormalTexTangentLayout is the layout of x, y, z, nx, ny, nz, u, v,
+	// tx, ty, tz, tw. (e.g. AddTangent())
+	PosNormalTexTangentLayout = VertexLayout{
+		{Name: "aPos", Location: 0, Size: 3},
+		{Name: "aNormal", Location: 1, Size: 3},
+		{Name: "aTexCoord", Location: 2, Size: 2},
+		{Name: "aTangent", Location: 3, Size: 4},
+	}
+
+	// PosTexLayout is the layout of x, y, z, u, v. (e.g. NewUniTexCube())
+	PosTexLayout = VertexLayout{
+		{Name: "aPos", Location: 0, Size: 3},
+		{Name: "aTexCoord", Location: 1, Size: 2},
+	}
+)
+
+// Stride returns the size of a vertex in bytes.
+func (l VertexLayout) Stride() int32 {
+	stride := int32(0)
+	for _, attr := range l {
+		stride += attr.Size * glTypeSize(attr.glType())
+	}
+	return stride
+}
+
+// Enable enables the attributes and sets their pointers for the
+// currently bound VAO and VBO. program is used to look up the attributes
+// whose locations are negative.
+func (l VertexLayout) Enable(program uint32) {
+	stride := l.Stride()
+	offset := int32(0)
+	for _, attr := range l {
+		location := attr.Location
+		if location < 0 {
+			location = gl.GetAttribLocation(program, gl.Str(attr.Name+"\x00"))
+		}
+		if location >= 0 {
+			gl.EnableVertexAttribArray(uint32(location))
+			gl.VertexAttribPointerWithOffset(
+				uint32(location),
+				attr.Size,
+				attr.glType(),
+				attr.Normalized,
+				stride,
+				uintptr(offset),
+			)
+		}
+		offset += attr.Size * glTypeSize(attr.glType())
+	}
+}
+
+func (attr VertexAttrib) glType() uint32 {
+	if attr.Type == 0 {
+		return gl.FLOAT
+	}
+	return attr.Type
+}
+
+// glTypeSize returns the size of a gl data type in bytes.
+func glTypeSize(glType uint32) int32 {
+	switch glType {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return 1
+	case gl.SHORT, gl.UNSIGNED_SHORT, gl.HALF_FLOAT:
+		return 2
+	case gl.DOUBLE:
+		return 8
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,57 @@
+package sgl
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+func TestVertexLayoutStride(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout VertexLayout
+		want   int32
+	}{
+		{"empty", VertexLayout{}, 0},
+		{"pos", PosLayout, 12},
+		{"pos normal", PosNormalLayout, 24},
+		{"pos normal color", PosNormalColorLayout, 36},
+		{"pos tex", PosTexLayout, 20},
+		{"mixed types", VertexLayout{
+			{Name: "aPos", Location: 0, Size: 3},
+			{Name: "aColor", Location: 1, Size: 4, Type: gl.UNSIGNED_BYTE, Normalized: true},
+			{Name: "aIndex", Location: 2, Size: 2, Type: gl.SHORT},
+			{Name: "aWeight", Location: 3, Size: 1, Type: gl.DOUBLE},
+		}, 12 + 4 + 4 + 8},
+	}
+	for _, tt := range tests {
+		if got := tt.layout.Stride(); got != tt.want {
+			t.Errorf("%v: got stride %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGlTypeSize(t *testing.T) {
+	tests := []struct {
+		glType uint32
+		want   int32
+	}{
+		{gl.BYTE, 1},
+		{gl.UNSIGNED_BYTE, 1},
+		{gl.SHORT, 2},
+		{gl.UNSIGNED_SHORT, 2},
+		{gl.HALF_FLOAT, 2},
+		{gl.INT, 4},
+		{gl.UNSIGNED_INT, 4},
+		{gl.FLOAT, 4},
+		{gl.DOUBLE, 8},
+	}
+	for _, tt := range tests {
+		if got := glTypeSize(tt.glType); got != tt.want {
+			t.Errorf("glTypeSize(0x%x) = %v, want %v", tt.glType, got, tt.want)
+		}
+	}
+	if got := (VertexAttrib{Size: 3}).glType(); got != gl.FLOAT {
+		t.Errorf("got default type 0x%x, want gl.FLOAT", got)
+	}
+}
@@ -1,6 +1,23 @@
 package sgl
 
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AddNormal computes the flat normals of the vertices of x, y, z, whose every
+// three vertices form a triangle, and returns the vertices of
+// x, y, z, nx, ny, nz. The normals face the side where the vertices of the
+// triangles are counter-clockwise, which is the convention of the shapes and
+// the STL, OBJ, PLY and glTF files. FixWinding() could fix the meshes whose
+// winding is inconsistent.
 func AddNormal(vertices []float32) []float32 {
+	return addFlatNormal(vertices, faceNormal)
+}
+
+// addFlatNormal is AddNormal() whose face normals are computed by faceNormalOf.
+func addFlatNormal(vertices []float32, faceNormalOf func(v1, v2, v3 mgl32.Vec3) mgl32.Vec3) []float32 {
 	newVertices := []float32{}
 	if len(vertices)%9 != 0 {
 		return vertices
@@ -9,63 +26,12 @@ func AddNormal(vertices []float32) []float32 {
 	// Three vertices construct a plane(triangle),
 	// therefore we'll get one normal every three points
 	for i := 0; i < len(vertices); i += 9 {
-		// pt1 : ( vertices[i], vertices[i+1], vertices[i+2] )
-		// pt2 : ( vertices[i+3], vertices[i+4], vertices[i+5] )
-		// pt3 : ( vertices[i+6], vertices[i+7], vertices[i+8] )
-		// vector: origin -> center of the triangle
-		center := []float32{
-			(vertices[i] + vertices[i+3] + vertices[i+6]) / 3,
-			(vertices[i+1] + vertices[i+4] + vertices[i+7]) / 3,
-			(vertices[i+2] + vertices[i+5] + vertices[i+8]) / 3,
-		}
-		// vector: pt1 -> pt2
-		vec1 := []float32{
-			vertices[i+3] - vertices[i],
-			vertices[i+4] - vertices[i+1],
-			vertices[i+5] - vertices[i+2],
-		}
-		// vector: pt2 -> pt3
-		vec2 := []float32{
-			vertices[i+6] - vertices[i+3],
-			vertices[i+7] - vertices[i+4],
-			vertices[i+8] - vertices[i+5],
-		}
-		// normal = vec1 x vec2 (cross product)
-		normal := []float32{
-			vec1[1]*vec2[2] - vec1[2]*vec2[1],
-			vec1[2]*vec2[0] - vec1[0]*vec2[2],
-			vec1[0]*vec2[1] - vec1[1]*vec2[0],
+		v1, v2, v3 := trianglePositions(vertices, i)
+		normal := faceNormalOf(v1, v2, v3)
+		for _, v := range []mgl32.Vec3{v1, v2, v3} {
+			newVertices = append(newVertices, v[0], v[1], v[2])
+			newVertices = append(newVertices, normal[0], normal[1], normal[2])
 		}
-		// check if normal . center (dot product) is negative
-		dot := normal[0]*center[0] + normal[1]*center[1] + normal[2]*center[2]
-		if dot < 0 {
-			normal = []float32{
-				-normal[0],
-				-normal[1],
-				-normal[2],
-			}
-		}
-		// newPt1
-		newVertices = append(newVertices, vertices[i])
-		newVertices = append(newVertices, vertices[i+1])
-		newVertices = append(newVertices, vertices[i+2])
-		newVertices = append(newVertices, normal[0])
-		newVertices = append(newVertices, normal[1])
-		newVertices = append(newVertices, normal[2])
-		// newPt2
-		newVertices = append(newVertices, vertices[i+3])
-		newVertices = append(newVertices, vertices[i+4])
-		newVertices = append(newVertices, vertices[i+5])
-		newVertices = append(newVertices, normal[0])
-		newVertices = append(newVertices, normal[1])
-		newVertices = append(newVertices, normal[2])
-		// newPt3
-		newVertices = append(newVertices, vertices[i+6])
-		newVertices = append(newVertices, vertices[i+7])
-		newVertices = append(newVertices, vertices[i+8])
-		newVertices = append(newVertices, normal[0])
-		newVertices = append(newVertices, normal[1])
-		newVertices = append(newVertices, normal[2])
 	}
 
 	// The output vertices will contain 6 * n float values,
@@ -75,3 +41,173 @@ func AddNormal(vertices []float32) []float32 {
 	// i.e. x, y, z, nx, ny, nz
 	return newVertices
 }
+
+// NormalWeighting is how the normals of the faces sharing a vertex are
+// weighted when they're averaged into a smooth normal.
+type NormalWeighting int
+
+const (
+	// AreaWeighted weights the normals of the faces by their areas, so the
+	// small faces affect the normals less.
+	AreaWeighted NormalWeighting = iota
+
+	// AngleWeighted weights the normals of the faces by their angles at the
+	// vertex, so the normals don't depend on how the faces are triangulated.
+	AngleWeighted
+)
+
+// NormalOptions are the options of computing the normals of the vertices.
+// The zero value makes the flat normals of AddNormal().
+type NormalOptions struct {
+	// Smooth averages the normals of the faces sharing a vertex, so curved
+	// surfaces like spheres and cylinders don't look faceted. The vertices
+	// are shared by the faces if their positions are equal.
+	Smooth bool
+
+	// Weighting is how the normals of the faces are weighted.
+	Weighting NormalWeighting
+
+	// CreaseAngle is the angle in radians above which the edge between two
+	// faces stays sharp, e.g. mgl32.DegToRad(30) keeps the edges of CAD
+	// parts. All the faces sharing a vertex are averaged if it's 0.
+	CreaseAngle float32
+
+	// OriginHeuristic ignores the winding order of the triangles, and flips
+	// the normals pointing toward the origin instead, which was how AddNormal()
+	// worked before. It only works for the convex meshes around the origin,
+	// but it could be used for the meshes whose winding is random.
+	OriginHeuristic bool
+}
+
+// AddNormalWithOptions is AddNormal() with the options of the normals. It
+// turns x, y, z into x, y, z, nx, ny, nz, and the normals are unit vectors.
+// The normals of the degenerate triangles are zero vectors.
+func AddNormalWithOptions(vertices []float32, opts NormalOptions) []float32 {
+	faceNormalOf := faceNormal
+	if opts.OriginHeuristic {
+		faceNormalOf = originFaceNormal
+	}
+	if !opts.Smooth {
+		return addFlatNormal(vertices, faceNormalOf)
+	}
+	if len(vertices)%9 != 0 {
+		return vertices
+	}
+	triNum := len(vertices) / 9
+
+	faceNormals := make([]mgl32.Vec3, triNum)
+	// the weights of the faces at their three corners
+	weights := make([][3]float32, triNum)
+	// the corners (triangle*3 + corner) sharing the same position
+	corners := map[[3]uint32][]int{}
+	for t := 0; t < triNum; t++ {
+		v1, v2, v3 := trianglePositions(vertices, t*9)
+		faceNormals[t] = faceNormalOf(v1, v2, v3)
+		area := v2.Sub(v1).Cross(v3.Sub(v1)).Len() / 2
+		pos := [3]mgl32.Vec3{v1, v2, v3}
+		for c := 0; c < 3; c++ {
+			if opts.Weighting == AngleWeighted {
+				weights[t][c] = cornerAngle(pos[c], pos[(c+1)%3], pos[(c+2)%3])
+			} else {
+				weights[t][c] = area
+			}
+			key := positionKey(pos[c])
+			corners[key] = append(corners[key], t*3+c)
+		}
+	}
+
+	cosCrease := float32(-1)
+	if opts.CreaseAngle > 0 {
+		cosCrease = float32(math.Cos(float64(opts.CreaseAngle)))
+	}
+	newVertices := make([]float32, 0, triNum*18)
+	for t := 0; t < triNum; t++ {
+		for c := 0; c < 3; c++ {
+			pos := mgl32.Vec3{vertices[t*9+c*3], vertices[t*9+c*3+1], vertices[t*9+c*3+2]}
+			normal := mgl32.Vec3{}
+			for _, corner := range corners[positionKey(pos)] {
+				other := corner / 3
+				// the faces beyond the crease angle keep the edge sharp
+				if faceNormals[other].Dot(faceNormals[t]) < cosCrease {
+					continue
+				}
+				normal = normal.Add(faceNormals[other].Mul(weights[other][corner%3]))
+			}
+			if normal.Len() > 0 {
+				normal = normal.Normalize()
+			} else {
+				normal = faceNormals[t]
+			}
+			newVertices = append(newVertices, pos[0], pos[1], pos[2])
+			newVertices = append(newVertices, normal[0], normal[1], normal[2])
+		}
+	}
+	return newVertices
+}
+
+// trianglePositions returns the positions of the triangle starting at i in
+// the vertex array of x, y, z.
+func trianglePositions(vertices []float32, i int) (mgl32.Vec3, mgl32.Vec3, mgl32.Vec3) {
+	return mgl32.Vec3{vertices[i], vertices[i+1], vertices[i+2]},
+		mgl32.Vec3{vertices[i+3], vertices[i+4], vertices[i+5]},
+		mgl32.Vec3{vertices[i+6], vertices[i+7], vertices[i+8]}
+}
+
+// originFaceNormal is faceNormal(), but the normal is flipped if it
+// points toward the origin, i.e. its dot product with the center of the
+// triangle is negative.
+func originFaceNormal(v1, v2, v3 mgl32.Vec3) mgl32.Vec3 {
+	// vector: origin -> center of the triangle
+	center := v1.Add(v2).Add(v3).Mul(1.0 / 3)
+	normal := faceNormal(v1, v2, v3)
+	// check if normal . center (dot product) is negative
+	if normal.Dot(center) < 0 {
+		normal = normal.Mul(-1)
+	}
+	return normal
+}
+
+// positionKey returns the key of the position to find the vertices at the
+// same position.
+func positionKey(pos mgl32.Vec3) [3]uint32 {
+	var key [3]uint32
+	for i, v := range pos {
+		if v == 0 {
+			v = 0 // -0 and 0 are the same position
+		}
+		key[i] = math.Float32bits(v)
+	}
+	return key
+}
+
+// faceNormal returns the unit normal of the triangle (v1, v2, v3) following
+// the counter-clockwise winding order, or a zero vector if the triangle
+// is degenerate.
+func faceNormal(v1, v2, v3 mgl32.Vec3) mgl32.Vec3 {
+	normal := v2.Sub(v1).Cross(v3.Sub(v1))
+	if normal.Len() == 0 {
+		return mgl32.Vec3{}
+	}
+	return normal.Normalize()
+}
+
+// AddNormalWithTexCoord is AddNormal() for the vertices with texture
+// coordinates. It turns x, y, z, u, v (e.g. NewUniTexCube()) into
+// x, y, z, nx, ny, nz, u, v.
+func AddNormalWithTexCoord(vertices []float32) []float32 {
+	if len(vertices)%15 != 0 {
+		return vertices
+	}
+	n := len(vertices) / 5
+	positions := make([]float32, 0, n*3)
+	for i := 0; i < len(vertices); i += 5 {
+		positions = append(positions, vertices[i:i+3]...)
+	}
+	withNormal := AddNormal(positions)
+	newVertices := make([]float32, 0, n*8)
+	for i := 0; i < n; i++ {
+		newVertices = append(newVertices, withNormal[i*6:i*6+6]...)
+		newVertices = append(newVertices, vertices[i*5+3:i*5+5]...)
+	}
+	return newVertices
+}
@@ -0,0 +1,156 @@
+package sgl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestAddNormal(t *testing.T) {
+	cube := *NewCube(2)
+	got := AddNormal(cube)
+	if len(got) != len(cube)*2 {
+		t.Fatalf("got %v values, want %v", len(got), len(cube)*2)
+	}
+	// the normals of the cube point outward
+	for i := 0; i < len(got); i += 6 {
+		pos := mgl32.Vec3{got[i], got[i+1], got[i+2]}
+		normal := mgl32.Vec3{got[i+3], got[i+4], got[i+5]}
+		if math.Abs(float64(normal.Len())-1) > 1e-6 || normal.Dot(pos) <= 0 {
+			t.Fatalf("vertex %v has normal %v", pos, normal)
+		}
+	}
+
+	if got := AddNormal(make([]float32, 10)); len(got) != 10 {
+		t.Errorf("got %v values, want the 10 values unchanged", len(got))
+	}
+}
+
+func TestAddNormalWithOptions(t *testing.T) {
+	// the triangles share only the vertex at the origin, and the one on the
+	// z = 0 plane is 4 times as large as the one on the x = 0 plane
+	roof := []float32{
+		0, 0, 0, 2, 0, 0, 0, 2, 0,
+		0, 0, 0, 0, 1, 0, 0, 0, 1,
+	}
+	up := mgl32.Vec3{0, 0, 1}
+	side := mgl32.Vec3{1, 0, 0}
+	area := mgl32.Vec3{0.5, 0, 2}.Normalize()
+	angle := mgl32.Vec3{1, 0, 1}.Normalize()
+	// clockwise seen from +Z
+	clockwise := []float32{0, 0, 1, 0, 1, 1, 1, 0, 1}
+	down := mgl32.Vec3{0, 0, -1}
+
+	tests := []struct {
+		name     string
+		vertices []float32
+		opts     NormalOptions
+		normals  []mgl32.Vec3
+	}{
+		{
+			name:     "flat",
+			vertices: roof,
+			opts:     NormalOptions{},
+			normals:  []mgl32.Vec3{up, up, up, side, side, side},
+		},
+		{
+			name:     "area weighted",
+			vertices: roof,
+			opts:     NormalOptions{Smooth: true},
+			normals:  []mgl32.Vec3{area, up, up, area, side, side},
+		},
+		{
+			name:     "angle weighted",
+			vertices: roof,
+			opts:     NormalOptions{Smooth: true, Weighting: AngleWeighted},
+			normals:  []mgl32.Vec3{angle, up, up, angle, side, side},
+		},
+		{
+			name:     "edge sharper than the crease angle",
+			vertices: roof,
+			opts:     NormalOptions{Smooth: true, CreaseAngle: mgl32.DegToRad(30)},
+			normals:  []mgl32.Vec3{up, up, up, side, side, side},
+		},
+		{
+			name:     "edge smoother than the crease angle",
+			vertices: roof,
+			opts:     NormalOptions{Smooth: true, CreaseAngle: mgl32.DegToRad(100)},
+			normals:  []mgl32.Vec3{area, up, up, area, side, side},
+		},
+		{
+			name:     "winding order",
+			vertices: clockwise,
+			opts:     NormalOptions{},
+			normals:  []mgl32.Vec3{down, down, down},
+		},
+		{
+			name:     "origin heuristic",
+			vertices: clockwise,
+			opts:     NormalOptions{OriginHeuristic: true},
+			normals:  []mgl32.Vec3{up, up, up},
+		},
+		{
+			name:     "smooth origin heuristic",
+			vertices: clockwise,
+			opts:     NormalOptions{Smooth: true, OriginHeuristic: true},
+			normals:  []mgl32.Vec3{up, up, up},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddNormalWithOptions(tt.vertices, tt.opts)
+			if len(got) != len(tt.normals)*6 {
+				t.Fatalf("got %v values, want %v", len(got), len(tt.normals)*6)
+			}
+			for i, want := range tt.normals {
+				v := got[i*6 : (i+1)*6]
+				if !equalFloats(v[:3], tt.vertices[i*3:(i+1)*3]) {
+					t.Errorf("vertex %v is at %v, want %v", i, v[:3], tt.vertices[i*3:(i+1)*3])
+				}
+				normal := mgl32.Vec3{v[3], v[4], v[5]}
+				if !normal.ApproxEqualThreshold(want, 1e-6) {
+					t.Errorf("vertex %v has normal %v, want %v", i, normal, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAddNormalWithOptionsCube(t *testing.T) {
+	cube := *NewCube(2)
+	if got := AddNormalWithOptions(cube, NormalOptions{}); !equalFloats(got, AddNormal(cube)) {
+		t.Errorf("got flat normals %v, want the ones of AddNormal()", got)
+	}
+
+	// every face has a right angle at every corner, so the angle weighted
+	// normals point to the corners
+	got := AddNormalWithOptions(cube, NormalOptions{Smooth: true, Weighting: AngleWeighted})
+	for i := 0; i < len(got); i += 6 {
+		pos := mgl32.Vec3{got[i], got[i+1], got[i+2]}
+		normal := mgl32.Vec3{got[i+3], got[i+4], got[i+5]}
+		if !normal.ApproxEqualThreshold(pos.Normalize(), 1e-6) {
+			t.Fatalf("vertex %v has normal %v, want %v", pos, normal, pos.Normalize())
+		}
+	}
+
+	// the edges of the cube are sharper than 60 degrees
+	got = AddNormalWithOptions(cube, NormalOptions{Smooth: true, CreaseAngle: mgl32.DegToRad(60)})
+	for i := 0; i < len(got); i += 6 {
+		if math.Abs(float64(got[i+3]+got[i+4]+got[i+5])) != 1 {
+			t.Fatalf("got normal %v, want the normal of a face", got[i+3:i+6])
+		}
+	}
+
+	degenerate := []float32{0, 0, 0, 1, 1, 1, 2, 2, 2}
+	for _, opts := range []NormalOptions{{}, {Smooth: true}} {
+		got := AddNormalWithOptions(degenerate, opts)
+		if got[3] != 0 || got[4] != 0 || got[5] != 0 {
+			t.Errorf("got normal %v of a degenerate triangle with %+v, want a zero vector", got[3:6], opts)
+		}
+	}
+
+	if got := AddNormalWithOptions(make([]float32, 10), NormalOptions{Smooth: true}); len(got) != 10 {
+		t.Errorf("got %v values, want the 10 values unchanged", len(got))
+	}
+}
@@ -3,13 +3,13 @@ package sgl
 import "github.com/go-gl/mathgl/mgl32"
 
 type Viewpoint struct {
-	Projection mgl32.Mat4
+	Projection mgl32.Mat4 `sgl:"projection"`
 	Fovy       float32
 	Aspect     float32
 	Near       float32
 	Far        float32
-	Camera     mgl32.Mat4
-	Eye        mgl32.Vec3
+	Camera     mgl32.Mat4 `sgl:"camera"`
+	Eye        mgl32.Vec3 `sgl:"viewPos"`
 	Target     mgl32.Vec3
 	Top        mgl32.Vec3
 }
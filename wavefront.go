@@ -0,0 +1,399 @@
+package sgl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ObjModel is a model read from a Wavefront OBJ file.
+type ObjModel struct {
+	// Meshes are the parts of the model. A new mesh starts whenever the
+	// object ("o"), the group ("g") or the material ("usemtl") changes.
+	Meshes []ObjMesh
+
+	// Materials maps the material names to the materials defined in
+	// the MTL files referenced by the OBJ file.
+	Materials map[string]Material
+
+	// MtlLibs are the MTL files referenced by "mtllib".
+	MtlLibs []string
+}
+
+// ObjMesh is a triangulated part of an ObjModel.
+type ObjMesh struct {
+	// Name is the name of the object or the group of the mesh.
+	Name string
+
+	// Material is the name of the material used by the mesh.
+	Material string
+
+	// Vertices uses 3 float32 values (X, Y, Z) to represent a vertex,
+	// so it could be used by SetVertices() directly.
+	Vertices []float32
+
+	// Normals uses 3 float32 values (NX, NY, NZ) for each vertex.
+	// If a face doesn't have normals, its normal is computed from the
+	// counter-clockwise winding order.
+	Normals []float32
+
+	// TexCoords uses 2 float32 values (U, V) for each vertex.
+	// It's (0, 0) if a face doesn't have texture coordinates.
+	TexCoords []float32
+}
+
+// VerticesWithNormal returns the vertex array that contains 6 float32 values
+// per vertex: x, y, z, nx, ny, nz, which could be used by
+// SimpleObj.SetVerticesWithNormal().
+func (m *ObjMesh) VerticesWithNormal() []float32 {
+	vertices := make([]float32, 0, len(m.Vertices)*2)
+	for i := 0; i < len(m.Vertices); i += 3 {
+		vertices = append(vertices, m.Vertices[i:i+3]...)
+		vertices = append(vertices, m.Normals[i:i+3]...)
+	}
+	return vertices
+}
+
+// VerticesWithTexCoord returns the vertex array that contains 5 float32 values
+// per vertex: x, y, z, u, v, which is the same layout as NewUniTexCube().
+func (m *ObjMesh) VerticesWithTexCoord() []float32 {
+	vertices := make([]float32, 0, len(m.Vertices)/3*5)
+	for i := 0; i < len(m.Vertices)/3; i++ {
+		vertices = append(vertices, m.Vertices[i*3:i*3+3]...)
+		vertices = append(vertices, m.TexCoords[i*2:i*2+2]...)
+	}
+	return vertices
+}
+
+// VerticesWithNormalAndTexCoord returns the vertex array that contains 8
+// float32 values per vertex: x, y, z, nx, ny, nz, u, v, which could be used
+// by TexObj.SetVerticesWithNormalAndTexCoord() and AddTangent().
+func (m *ObjMesh) VerticesWithNormalAndTexCoord() []float32 {
+	vertices := make([]float32, 0, len(m.Vertices)/3*8)
+	for i := 0; i < len(m.Vertices)/3; i++ {
+		vertices = append(vertices, m.Vertices[i*3:i*3+3]...)
+		vertices = append(vertices, m.Normals[i*3:i*3+3]...)
+		vertices = append(vertices, m.TexCoords[i*2:i*2+2]...)
+	}
+	return vertices
+}
+
+// ObjSyntaxError is returned when an OBJ or MTL input is malformed.
+type ObjSyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ObjSyntaxError) Error() string {
+	return fmt.Sprintf("obj syntax error at line %v: %v", e.Line, e.Msg)
+}
+
+// ReadObjFile reads a Wavefront OBJ file and the MTL files it references.
+// The MTL files are looked up relative to the directory of the OBJ file.
+func ReadObjFile(file string) (*ObjModel, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	model, err := ReadObj(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", file, err)
+	}
+	for _, lib := range model.MtlLibs {
+		path := filepath.Join(filepath.Dir(file), lib)
+		mf, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		materials, err := ReadMtl(mf)
+		mf.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+		for name, mt := range materials {
+			model.Materials[name] = mt
+		}
+	}
+	return model, nil
+}
+
+// ReadObj reads Wavefront OBJ data from r. Polygons are triangulated as
+// triangle fans, so they are expected to be convex.
+// The MTL files referenced by the data are not read, they are listed in
+// ObjModel.MtlLibs and could be read by ReadMtl().
+func ReadObj(r io.Reader) (*ObjModel, error) {
+	model := &ObjModel{Materials: map[string]Material{}}
+	positions := [][3]float32{}
+	normals := [][3]float32{}
+	texCoords := [][2]float32{}
+	mesh := &ObjMesh{}
+	name := ""
+	material := ""
+
+	// flush starts a new mesh if the current one has faces
+	flush := func() {
+		if len(mesh.Vertices) > 0 {
+			model.Meshes = append(model.Meshes, *mesh)
+		}
+		mesh = &ObjMesh{Name: name, Material: material}
+	}
+
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			vec, ok := parseObjFloats(fields[1:], 3)
+			if !ok {
+				return nil, &ObjSyntaxError{Line: line, Msg: "invalid vertex position"}
+			}
+			positions = append(positions, [3]float32{vec[0], vec[1], vec[2]})
+		case "vn":
+			vec, ok := parseObjFloats(fields[1:], 3)
+			if !ok {
+				return nil, &ObjSyntaxError{Line: line, Msg: "invalid vertex normal"}
+			}
+			normals = append(normals, [3]float32{vec[0], vec[1], vec[2]})
+		case "vt":
+			vec, ok := parseObjFloats(fields[1:], 1)
+			if !ok {
+				return nil, &ObjSyntaxError{Line: line, Msg: "invalid texture coordinate"}
+			}
+			vec = append(vec, 0)
+			texCoords = append(texCoords, [2]float32{vec[0], vec[1]})
+		case "f":
+			if len(fields) < 4 {
+				return nil, &ObjSyntaxError{Line: line, Msg: "face has less than 3 vertices"}
+			}
+			face := make([]objFaceVert, len(fields)-1)
+			for i, field := range fields[1:] {
+				fv, err := parseObjFaceVert(
+					field,
+					len(positions),
+					len(texCoords),
+					len(normals),
+				)
+				if err != nil {
+					return nil, &ObjSyntaxError{Line: line, Msg: err.Error()}
+				}
+				face[i] = fv
+			}
+			// triangle fan: (0, 1, 2), (0, 2, 3), ...
+			for i := 1; i < len(face)-1; i++ {
+				mesh.addTriangle(
+					[3]objFaceVert{face[0], face[i], face[i+1]},
+					positions,
+					texCoords,
+					normals,
+				)
+			}
+		case "o", "g":
+			name = strings.Join(fields[1:], " ")
+			flush()
+		case "usemtl":
+			material = strings.Join(fields[1:], " ")
+			flush()
+		case "mtllib":
+			model.MtlLibs = append(model.MtlLibs, fields[1:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return model, nil
+}
+
+// ReadMtl reads the materials from Wavefront MTL data.
+// Ka, Kd, Ks and Ns are mapped to Material's Ambient, Diffuse, Specular and
+// Shininess, and the values that are not defined are taken from NewMaterial().
+func ReadMtl(r io.Reader) (map[string]Material, error) {
+	materials := map[string]Material{}
+	name := ""
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "newmtl" {
+			name = strings.Join(fields[1:], " ")
+			materials[name] = NewMaterial()
+			continue
+		}
+		mt, ok := materials[name]
+		if !ok {
+			continue
+		}
+		switch fields[0] {
+		case "Ka", "Kd", "Ks":
+			vec, ok := parseObjFloats(fields[1:], 3)
+			if !ok {
+				return nil, &ObjSyntaxError{Line: line, Msg: "invalid " + fields[0]}
+			}
+			color := mgl32.Vec3{vec[0], vec[1], vec[2]}
+			switch fields[0] {
+			case "Ka":
+				mt.Ambient = color
+			case "Kd":
+				mt.Diffuse = color
+			case "Ks":
+				mt.Specular = color
+			}
+		case "Ns":
+			vec, ok := parseObjFloats(fields[1:], 1)
+			if !ok {
+				return nil, &ObjSyntaxError{Line: line, Msg: "invalid Ns"}
+			}
+			mt.Shininess = vec[0]
+		}
+		materials[name] = mt
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
+
+// NewObjGroup returns a Group that contains a SimpleObj for each mesh of
+// the model. The objects are named after their meshes, and they use the
+// materials of their meshes (or NewMaterial() if the material isn't found).
+// All the objects share the same program.
+func NewObjGroup(model *ObjModel, vp *Viewpoint, ls *LightSrc) Group {
+	g := NewGroup()
+	program := uint32(0)
+	for i := range model.Meshes {
+		mesh := &model.Meshes[i]
+		mt, ok := model.Materials[mesh.Material]
+		if !ok {
+			mt = NewMaterial()
+		}
+
+		obj := &SimpleObj{}
+		if program == 0 {
+			program = makeBuiltinProgram(getSimpleObjVS(), getSimpleObjFS())
+			g.programs = append(g.programs, program)
+		}
+		obj.SetProgram(program)
+		obj.SetProgVar(SimpleObjVar{
+			Red:   1,
+			Green: 1,
+			Blue:  1,
+			Vp:    vp,
+			Ls:    ls,
+			Mt:    &mt,
+		})
+		vertices := mesh.VerticesWithNormal()
+		obj.SetVerticesWithNormal(&vertices)
+		obj.SetModel(mgl32.Ident4())
+
+		name := mesh.Name
+		if _, ok := g.objects[name]; ok || name == "" {
+			name = fmt.Sprintf("%v_%v", mesh.Name, i)
+		}
+		g.AddObject(name, obj)
+	}
+	return g
+}
+
+// objFaceVert keeps the 0-based indices of a face vertex.
+// The texture coordinate and the normal indices are -1 if not defined.
+type objFaceVert struct {
+	pos    int
+	tex    int
+	normal int
+}
+
+// parseObjFaceVert parses "v", "v/vt", "v//vn" or "v/vt/vn".
+// The indices start from 1, and the negative indices are relative to
+// the end of the lists.
+func parseObjFaceVert(field string, posNum, texNum, normalNum int) (objFaceVert, error) {
+	fv := objFaceVert{tex: -1, normal: -1}
+	parts := strings.Split(field, "/")
+	if len(parts) > 3 {
+		return fv, fmt.Errorf("invalid face vertex %q", field)
+	}
+	nums := []int{posNum, texNum, normalNum}
+	indices := []*int{&fv.pos, &fv.tex, &fv.normal}
+	for i, part := range parts {
+		if part == "" {
+			if i == 0 {
+				return fv, fmt.Errorf("face vertex %q has no position", field)
+			}
+			continue
+		}
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return fv, fmt.Errorf("invalid face vertex %q", field)
+		}
+		if idx < 0 {
+			idx += nums[i]
+		} else {
+			idx--
+		}
+		if idx < 0 || idx >= nums[i] {
+			return fv, fmt.Errorf("face vertex %q is out of range", field)
+		}
+		*indices[i] = idx
+	}
+	return fv, nil
+}
+
+// addTriangle appends a triangle to the mesh.
+func (m *ObjMesh) addTriangle(
+	tri [3]objFaceVert,
+	positions [][3]float32,
+	texCoords [][2]float32,
+	normals [][3]float32,
+) {
+	normal := faceNormal(
+		positions[tri[0].pos],
+		positions[tri[1].pos],
+		positions[tri[2].pos],
+	)
+	for _, fv := range tri {
+		pos := positions[fv.pos]
+		m.Vertices = append(m.Vertices, pos[0], pos[1], pos[2])
+		if fv.normal >= 0 {
+			n := normals[fv.normal]
+			m.Normals = append(m.Normals, n[0], n[1], n[2])
+		} else {
+			m.Normals = append(m.Normals, normal[0], normal[1], normal[2])
+		}
+		tex := [2]float32{}
+		if fv.tex >= 0 {
+			tex = texCoords[fv.tex]
+		}
+		m.TexCoords = append(m.TexCoords, tex[0], tex[1])
+	}
+}
+
+// parseObjFloats parses at least n float strings.
+func parseObjFloats(fields []string, n int) ([]float32, bool) {
+	if len(fields) < n {
+		return nil, false
+	}
+	vec := make([]float32, 0, len(fields))
+	for _, field := range fields {
+		v, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return nil, false
+		}
+		vec = append(vec, float32(v))
+	}
+	return vec, true
+}
@@ -0,0 +1,207 @@
+package sgl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const objQuad = `# a unit quad
+mtllib quad.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+o quad
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1
+`
+
+func TestReadObj(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		meshes    []string
+		triangles []int
+	}{
+		{
+			name:      "quad",
+			input:     objQuad,
+			meshes:    []string{"quad"},
+			triangles: []int{2},
+		},
+		{
+			name:      "negative indices",
+			input:     "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -3 -2 -1\n",
+			meshes:    []string{""},
+			triangles: []int{1},
+		},
+		{
+			name:      "positions and normals",
+			input:     "v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 1\nf 1//1 2//1 3//1\n",
+			meshes:    []string{""},
+			triangles: []int{1},
+		},
+		{
+			name: "objects and materials",
+			input: "v 0 0 0\nv 1 0 0\nv 0 1 0\n" +
+				"o a\nf 1 2 3\nusemtl m\nf 1 2 3\nf 3 2 1\no b\ng c\nf 1 2 3\n",
+			meshes:    []string{"a", "a", "c"},
+			triangles: []int{1, 2, 1},
+		},
+		{
+			name:      "no faces",
+			input:     "v 0 0 0\n",
+			meshes:    []string{},
+			triangles: []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := ReadObj(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(model.Meshes) != len(tt.meshes) {
+				t.Fatalf("got %v meshes, want %v", len(model.Meshes), len(tt.meshes))
+			}
+			for i, mesh := range model.Meshes {
+				if mesh.Name != tt.meshes[i] {
+					t.Errorf("mesh %v is %q, want %q", i, mesh.Name, tt.meshes[i])
+				}
+				n := len(mesh.Vertices) / 9
+				if n != tt.triangles[i] {
+					t.Errorf("mesh %v has %v triangles, want %v", i, n, tt.triangles[i])
+				}
+				if len(mesh.Normals) != len(mesh.Vertices) ||
+					len(mesh.TexCoords) != len(mesh.Vertices)/3*2 {
+					t.Errorf("mesh %v has %v normals and %v texture coordinates for %v vertices",
+						i, len(mesh.Normals)/3, len(mesh.TexCoords)/2, len(mesh.Vertices)/3)
+				}
+			}
+		})
+	}
+}
+
+func TestReadObjQuad(t *testing.T) {
+	model, err := ReadObj(strings.NewReader(objQuad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.MtlLibs) != 1 || model.MtlLibs[0] != "quad.mtl" {
+		t.Errorf("got MtlLibs %v", model.MtlLibs)
+	}
+	mesh := model.Meshes[0]
+	if mesh.Material != "red" {
+		t.Errorf("got material %q, want \"red\"", mesh.Material)
+	}
+	// triangle fan (1, 2, 3), (1, 3, 4)
+	want := []float32{
+		0, 0, 0, 1, 0, 0, 1, 1, 0,
+		0, 0, 0, 1, 1, 0, 0, 1, 0,
+	}
+	if !equalFloats(mesh.Vertices, want) {
+		t.Errorf("got vertices %v, want %v", mesh.Vertices, want)
+	}
+	wantTex := []float32{0, 0, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1}
+	if !equalFloats(mesh.TexCoords, wantTex) {
+		t.Errorf("got texture coordinates %v, want %v", mesh.TexCoords, wantTex)
+	}
+	withNormal := mesh.VerticesWithNormal()
+	if len(withNormal) != 6*6 || withNormal[5] != 1 || withNormal[11] != 1 {
+		t.Errorf("got VerticesWithNormal() %v", withNormal)
+	}
+	withTex := mesh.VerticesWithTexCoord()
+	if len(withTex) != 6*5 || withTex[8] != 1 || withTex[9] != 0 {
+		t.Errorf("got VerticesWithTexCoord() %v", withTex)
+	}
+	withBoth := mesh.VerticesWithNormalAndTexCoord()
+	if len(withBoth) != 6*8 || withBoth[5] != 1 || withBoth[14] != 1 {
+		t.Errorf("got VerticesWithNormalAndTexCoord() %v", withBoth)
+	}
+}
+
+func TestReadObjComputedNormal(t *testing.T) {
+	// clockwise seen from +Z, so the normal is -Z
+	model, err := ReadObj(strings.NewReader("v 0 0 5\nv 0 1 5\nv 1 0 5\nf 1 2 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{0, 0, -1, 0, 0, -1, 0, 0, -1}
+	if !equalFloats(model.Meshes[0].Normals, want) {
+		t.Errorf("got normals %v, want %v", model.Meshes[0].Normals, want)
+	}
+}
+
+func TestReadObjErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{"invalid position", "v 0 x 0\n", 1},
+		{"short position", "v 0 0\n", 1},
+		{"invalid normal", "vn 0 0\n", 1},
+		{"invalid texture coordinate", "vt\n", 1},
+		{"two vertices", "v 0 0 0\nv 1 0 0\nf 1 2\n", 3},
+		{"out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", 4},
+		{"zero index", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 0 1 2\n", 4},
+		{"negative out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -4 1 2\n", 4},
+		{"normal out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//1 2//1 3//1\n", 4},
+		{"no position", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf /1 2 3\n", 4},
+		{"too many parts", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n", 4},
+		{"not a number", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf a 2 3\n", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadObj(strings.NewReader(tt.input))
+			var syntaxErr *ObjSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got error %v, want an ObjSyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("got the error at line %v, want %v", syntaxErr.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestReadMtl(t *testing.T) {
+	input := `# materials
+newmtl red
+Kd 1 0 0
+Ns 64
+
+newmtl plain
+Ks 0 0 0
+`
+	materials, err := ReadMtl(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(materials) != 2 {
+		t.Fatalf("got %v materials, want 2", len(materials))
+	}
+	def := NewMaterial()
+	red := materials["red"]
+	if red.Diffuse != (mgl32.Vec3{1, 0, 0}) || red.Shininess != 64 || red.Ambient != def.Ambient {
+		t.Errorf("got red %+v", red)
+	}
+	plain := materials["plain"]
+	if plain.Specular != (mgl32.Vec3{}) || plain.Diffuse != def.Diffuse {
+		t.Errorf("got plain %+v", plain)
+	}
+
+	_, err = ReadMtl(strings.NewReader("newmtl bad\nKd 1 0\n"))
+	var syntaxErr *ObjSyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2 {
+		t.Errorf("got error %v, want an ObjSyntaxError at line 2", err)
+	}
+}